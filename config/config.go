@@ -25,6 +25,27 @@ import (
 // Activated - "yes" keyword to activate a service
 const Activated string = "yes"
 
+// Default values for retrying failed database connections
+const (
+	DefaultMaxRetries    int           = 5
+	DefaultRetryDelay    time.Duration = 1 * time.Second
+	DefaultRetryMaxDelay time.Duration = 30 * time.Second
+)
+
+// DefaultSlowThreshold - queries taking longer (in milliseconds) are
+// logged at Warn level
+const DefaultSlowThreshold int = 200
+
+// DefaultBatchSize - number of rows inserted per statement by
+// database.BatchCreate
+const DefaultBatchSize int = 100
+
+// Default values of the mysql connection
+const (
+	DefaultMySQLCharset string = "utf8mb4"
+	DefaultMySQLLoc     string = "Local"
+)
+
 // PrefixJtiBlacklist - to manage JWT blacklist in Redis database
 const PrefixJtiBlacklist string = "gorest-blacklist-jti:"
 
@@ -138,10 +159,45 @@ func databaseRDBMS() (databaseConfig DatabaseConfig, err error) {
 	databaseConfig.RDBMS.Env.Host = strings.TrimSpace(os.Getenv("DBHOST"))
 	databaseConfig.RDBMS.Env.Port = strings.TrimSpace(os.Getenv("DBPORT"))
 	databaseConfig.RDBMS.Env.TimeZone = strings.TrimSpace(os.Getenv("DBTIMEZONE"))
+	databaseConfig.RDBMS.Env.Socket = strings.TrimSpace(os.Getenv("DBSOCKET"))
+	if strings.ToLower(strings.TrimSpace(os.Getenv("DBFORCE_UTC"))) == Activated {
+		databaseConfig.RDBMS.Env.ForceUTC = true
+	}
+	databaseConfig.RDBMS.Env.SSHTunnel.Host = strings.TrimSpace(os.Getenv("DBSSH_HOST"))
+	databaseConfig.RDBMS.Env.SSHTunnel.User = strings.TrimSpace(os.Getenv("DBSSH_USER"))
+	databaseConfig.RDBMS.Env.SSHTunnel.KeyPath = strings.TrimSpace(os.Getenv("DBSSH_KEY"))
+	databaseConfig.RDBMS.Env.SSHTunnel.KnownHostsPath = strings.TrimSpace(os.Getenv("DBSSH_KNOWN_HOSTS"))
+	databaseConfig.RDBMS.Env.AppName = strings.TrimSpace(os.Getenv("DBAPP_NAME"))
+	if databaseConfig.RDBMS.Env.AppName == "" {
+		databaseConfig.RDBMS.Env.AppName = strings.TrimSpace(os.Getenv("APP_NAME"))
+	}
+	databaseConfig.RDBMS.Env.Schema = strings.TrimSpace(os.Getenv("DBSCHEMA"))
+	// mysql
+	databaseConfig.RDBMS.Env.Charset = strings.TrimSpace(os.Getenv("DBCHARSET"))
+	if databaseConfig.RDBMS.Env.Charset == "" {
+		databaseConfig.RDBMS.Env.Charset = DefaultMySQLCharset
+	}
+	databaseConfig.RDBMS.Env.Collation = strings.TrimSpace(os.Getenv("DBCOLLATION"))
+	databaseConfig.RDBMS.Env.Loc = strings.TrimSpace(os.Getenv("DBLOC"))
+	if databaseConfig.RDBMS.Env.Loc == "" {
+		databaseConfig.RDBMS.Env.Loc = DefaultMySQLLoc
+	}
+	// enabled unless explicitly disabled
+	databaseConfig.RDBMS.Env.ParseTime = strings.ToLower(strings.TrimSpace(os.Getenv("DBPARSETIME"))) != "no"
+	databaseConfig.RDBMS.Env.TablePrefix = strings.TrimSpace(os.Getenv("DBTABLE_PREFIX"))
+	if strings.ToLower(strings.TrimSpace(os.Getenv("DBSINGULAR_TABLE"))) == Activated {
+		databaseConfig.RDBMS.Env.SingularTable = true
+	}
+	dbDisableFKConstraint := strings.ToLower(strings.TrimSpace(os.Getenv("DBDISABLE_FK_CONSTRAINT_ON_MIGRATE")))
+	if dbDisableFKConstraint != "" {
+		disableFKConstraint := dbDisableFKConstraint == Activated
+		databaseConfig.RDBMS.Env.DisableFKConstraintOnMigrate = &disableFKConstraint
+	}
 	// Access
 	databaseConfig.RDBMS.Access.DbName = strings.TrimSpace(os.Getenv("DBNAME"))
 	databaseConfig.RDBMS.Access.User = strings.TrimSpace(os.Getenv("DBUSER"))
 	databaseConfig.RDBMS.Access.Pass = strings.TrimSpace(os.Getenv("DBPASS"))
+	databaseConfig.RDBMS.Access.URL = strings.TrimSpace(os.Getenv("DBURL"))
 	// SSL
 	databaseConfig.RDBMS.Ssl.Sslmode = strings.TrimSpace(os.Getenv("DBSSLMODE"))
 	databaseConfig.RDBMS.Ssl.MinTLS = strings.TrimSpace(os.Getenv("DBSSL_TLS_MIN"))
@@ -165,6 +221,61 @@ func databaseRDBMS() (databaseConfig DatabaseConfig, err error) {
 	if err != nil {
 		return
 	}
+	dbConnectTimeout := strings.TrimSpace(os.Getenv("DBCONNECT_TIMEOUT"))
+	if dbConnectTimeout != "" {
+		databaseConfig.RDBMS.Conn.ConnectTimeout, err = time.ParseDuration(dbConnectTimeout)
+		if err != nil {
+			return
+		}
+	}
+	dbQueryTimeout := strings.TrimSpace(os.Getenv("DBQUERY_TIMEOUT"))
+	if dbQueryTimeout != "" {
+		databaseConfig.RDBMS.Conn.QueryTimeout, err = time.ParseDuration(dbQueryTimeout)
+		if err != nil {
+			return
+		}
+	}
+	dbAcquireTimeout := strings.TrimSpace(os.Getenv("DBACQUIRE_TIMEOUT"))
+	if dbAcquireTimeout != "" {
+		databaseConfig.RDBMS.Conn.AcquireTimeout, err = time.ParseDuration(dbAcquireTimeout)
+		if err != nil {
+			return
+		}
+	}
+	dbConnMaxIdleTime := strings.TrimSpace(os.Getenv("DBCONNMAXIDLETIME"))
+	if dbConnMaxIdleTime != "" {
+		databaseConfig.RDBMS.Conn.ConnMaxIdleTime, err = time.ParseDuration(dbConnMaxIdleTime)
+		if err != nil {
+			return
+		}
+	}
+	maxRetries, retryDelay, retryMaxDelay, err := getParamsRetry("DB")
+	if err != nil {
+		return
+	}
+	databaseConfig.RDBMS.Conn.MaxRetries = maxRetries
+	databaseConfig.RDBMS.Conn.RetryDelay = retryDelay
+	databaseConfig.RDBMS.Conn.RetryMaxDelay = retryMaxDelay
+	databaseConfig.RDBMS.Conn.Compression = strings.ToLower(strings.TrimSpace(os.Getenv("DBCOMPRESSION")))
+	if strings.ToLower(strings.TrimSpace(os.Getenv("DBPREFER_SIMPLE_PROTOCOL"))) == Activated {
+		databaseConfig.RDBMS.Conn.PreferSimpleProtocol = true
+	}
+	if strings.ToLower(strings.TrimSpace(os.Getenv("DBPREPARE_STMT"))) == Activated {
+		databaseConfig.RDBMS.Conn.PrepareStmt = true
+	}
+	if strings.ToLower(strings.TrimSpace(os.Getenv("DBSKIP_DEFAULT_TRANSACTION"))) == Activated {
+		databaseConfig.RDBMS.Conn.SkipDefaultTransaction = true
+	}
+	dbWarmUp := strings.TrimSpace(os.Getenv("DBWARMUP"))
+	if dbWarmUp != "" {
+		databaseConfig.RDBMS.Conn.WarmUp, err = strconv.Atoi(dbWarmUp)
+		if err != nil {
+			return
+		}
+	}
+	// Read replicas
+	databaseConfig.RDBMS.Replica.Hosts = splitList(os.Getenv("DBREPLICA_HOSTS"))
+	databaseConfig.RDBMS.Replica.URLs = splitList(os.Getenv("DBREPLICA_URLS"))
 
 	// Logger
 	dbLogLevel := strings.TrimSpace(os.Getenv("DBLOGLEVEL"))
@@ -172,6 +283,29 @@ func databaseRDBMS() (databaseConfig DatabaseConfig, err error) {
 	if err != nil {
 		return
 	}
+	if databaseConfig.RDBMS.Log.LogLevel < 1 || databaseConfig.RDBMS.Log.LogLevel > 4 {
+		err = errors.New("DBLOGLEVEL must be between 1 (silent) and 4 (info)")
+		return
+	}
+	databaseConfig.RDBMS.Conn.BatchSize = DefaultBatchSize
+	dbBatchSize := strings.TrimSpace(os.Getenv("DBBATCH_SIZE"))
+	if dbBatchSize != "" {
+		databaseConfig.RDBMS.Conn.BatchSize, err = strconv.Atoi(dbBatchSize)
+		if err != nil {
+			return
+		}
+	}
+	databaseConfig.RDBMS.Log.SlowThreshold = DefaultSlowThreshold
+	dbSlowThreshold := strings.TrimSpace(os.Getenv("DBSLOWTHRESHOLD"))
+	if dbSlowThreshold != "" {
+		databaseConfig.RDBMS.Log.SlowThreshold, err = strconv.Atoi(dbSlowThreshold)
+		if err != nil {
+			return
+		}
+	}
+	if strings.ToLower(strings.TrimSpace(os.Getenv("DBTRACING"))) == Activated {
+		databaseConfig.RDBMS.Log.EnableTracing = true
+	}
 
 	return
 }
@@ -192,8 +326,54 @@ func databaseRedis() (databaseConfig DatabaseConfig, err error) {
 
 	databaseConfig.REDIS.Env.Host = strings.TrimSpace(os.Getenv("REDISHOST"))
 	databaseConfig.REDIS.Env.Port = strings.TrimSpace(os.Getenv("REDISPORT"))
+	databaseConfig.REDIS.Access.User = strings.TrimSpace(os.Getenv("REDISUSER"))
+	databaseConfig.REDIS.Access.Pass = strings.TrimSpace(os.Getenv("REDISPASS"))
 	databaseConfig.REDIS.Conn.PoolSize = poolSize
 	databaseConfig.REDIS.Conn.ConnTTL = connTTL
+	redisDB := strings.TrimSpace(os.Getenv("REDISDB"))
+	if redisDB != "" {
+		databaseConfig.REDIS.Conn.DB, err = strconv.Atoi(redisDB)
+		if err != nil {
+			return
+		}
+	}
+	for env, value := range map[string]*time.Duration{
+		"REDIS_DIAL_TIMEOUT":          &databaseConfig.REDIS.Conn.DialTimeout,
+		"REDIS_READ_TIMEOUT":          &databaseConfig.REDIS.Conn.ReadTimeout,
+		"REDIS_WRITE_TIMEOUT":         &databaseConfig.REDIS.Conn.WriteTimeout,
+		"REDIS_MAX_LIFETIME":          &databaseConfig.REDIS.Conn.MaxLifetime,
+		"REDIS_HEALTH_CHECK_INTERVAL": &databaseConfig.REDIS.Conn.HealthCheckInterval,
+	} {
+		duration := strings.TrimSpace(os.Getenv(env))
+		if duration != "" {
+			*value, err = time.ParseDuration(duration)
+			if err != nil {
+				return
+			}
+		}
+	}
+	if strings.ToLower(strings.TrimSpace(os.Getenv("REDIS_USE_TLS"))) == Activated {
+		databaseConfig.REDIS.Conn.UseTLS = true
+	}
+	databaseConfig.REDIS.Conn.CACert = strings.TrimSpace(os.Getenv("REDIS_TLS_CA_CERT"))
+	if strings.ToLower(strings.TrimSpace(os.Getenv("REDIS_TLS_INSECURE_SKIP_VERIFY"))) == Activated {
+		databaseConfig.REDIS.Conn.InsecureSkipVerify = true
+	}
+	databaseConfig.REDIS.Sentinel.MasterName = strings.TrimSpace(os.Getenv("REDIS_SENTINEL_MASTER"))
+	databaseConfig.REDIS.Sentinel.Addrs = splitList(os.Getenv("REDIS_SENTINEL_ADDRS"))
+	databaseConfig.REDIS.Cluster.Addrs = splitList(os.Getenv("REDIS_CLUSTER_ADDRS"))
+
+	return
+}
+
+// splitList - split a comma-separated list, empty items are dropped
+func splitList(value string) (list []string) {
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
 
 	return
 }
@@ -211,12 +391,69 @@ func databaseMongo() (databaseConfig DatabaseConfig, err error) {
 		err = errThis
 		return
 	}
+	maxRetries, retryDelay, retryMaxDelay, errThis := getParamsRetry("MONGO_")
+	if errThis != nil {
+		err = errThis
+		return
+	}
 
 	databaseConfig.MongoDB.Env.URI = strings.TrimSpace(os.Getenv("MONGO_URI"))
 	databaseConfig.MongoDB.Env.AppName = strings.TrimSpace(os.Getenv("MONGO_APP"))
+	databaseConfig.MongoDB.Env.DatabaseName = strings.TrimSpace(os.Getenv("MONGO_DATABASE"))
 	databaseConfig.MongoDB.Env.PoolSize = poolSize
 	databaseConfig.MongoDB.Env.PoolMon = strings.TrimSpace(os.Getenv("MONGO_MONITOR_POOL"))
 	databaseConfig.MongoDB.Env.ConnTTL = connTTL
+	databaseConfig.MongoDB.Env.MaxRetries = maxRetries
+	databaseConfig.MongoDB.Env.RetryDelay = retryDelay
+	databaseConfig.MongoDB.Env.RetryMaxDelay = retryMaxDelay
+	for env, value := range map[string]*time.Duration{
+		"MONGO_CONNECT_TIMEOUT":          &databaseConfig.MongoDB.Env.ConnectTimeout,
+		"MONGO_SERVER_SELECTION_TIMEOUT": &databaseConfig.MongoDB.Env.ServerSelectionTimeout,
+		"MONGO_SOCKET_TIMEOUT":           &databaseConfig.MongoDB.Env.SocketTimeout,
+	} {
+		duration := strings.TrimSpace(os.Getenv(env))
+		if duration != "" {
+			*value, err = time.ParseDuration(duration)
+			if err != nil {
+				return
+			}
+		}
+	}
+	databaseConfig.MongoDB.Env.Compressors = splitList(os.Getenv("MONGO_COMPRESSORS"))
+	// the stable API is pinned unless it is explicitly disabled
+	databaseConfig.MongoDB.Env.EnableServerAPI = strings.ToLower(strings.TrimSpace(os.Getenv("MONGO_SERVER_API"))) != "no"
+	databaseConfig.MongoDB.Env.WriteConcern = strings.TrimSpace(os.Getenv("MONGO_WRITE_CONCERN"))
+	databaseConfig.MongoDB.Env.ReadConcern = strings.TrimSpace(os.Getenv("MONGO_READ_CONCERN"))
+	databaseConfig.MongoDB.Env.ReadPreference = strings.TrimSpace(os.Getenv("MONGO_READ_PREFERENCE"))
+
+	return
+}
+
+// getParamsRetry - read the connection retry parameters from env,
+// the keys are prefixed with the given prefix
+func getParamsRetry(prefix string) (maxRetries int, retryDelay, retryMaxDelay time.Duration, err error) {
+	maxRetries = DefaultMaxRetries
+	retryDelay = DefaultRetryDelay
+	retryMaxDelay = DefaultRetryMaxDelay
+
+	if value := strings.TrimSpace(os.Getenv(prefix + "MAXRETRIES")); value != "" {
+		maxRetries, err = strconv.Atoi(value)
+		if err != nil {
+			return
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv(prefix + "RETRYDELAY")); value != "" {
+		retryDelay, err = time.ParseDuration(value)
+		if err != nil {
+			return
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv(prefix + "RETRYMAXDELAY")); value != "" {
+		retryMaxDelay, err = time.ParseDuration(value)
+		if err != nil {
+			return
+		}
+	}
 
 	return
 }
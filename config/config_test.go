@@ -90,6 +90,9 @@ func TestGetConfig(t *testing.T) {
 	expected.Database.RDBMS.Env.Host = "127.0.0.1"
 	expected.Database.RDBMS.Env.Port = "3306"
 	expected.Database.RDBMS.Env.TimeZone = "Europe/Berlin"
+	expected.Database.RDBMS.Env.Charset = config.DefaultMySQLCharset
+	expected.Database.RDBMS.Env.Loc = config.DefaultMySQLLoc
+	expected.Database.RDBMS.Env.ParseTime = true
 	expected.Database.RDBMS.Access.DbName = "test_database"
 	expected.Database.RDBMS.Access.User = "test_user"
 	expected.Database.RDBMS.Access.Pass = "test_password"
@@ -102,7 +105,14 @@ func TestGetConfig(t *testing.T) {
 	expected.Database.RDBMS.Conn.MaxIdleConns = 10
 	expected.Database.RDBMS.Conn.MaxOpenConns = 100
 	expected.Database.RDBMS.Conn.ConnMaxLifetime = time.Duration(1 * time.Hour)
+	expected.Database.RDBMS.Conn.MaxRetries = config.DefaultMaxRetries
+	expected.Database.RDBMS.Conn.RetryDelay = config.DefaultRetryDelay
+	expected.Database.RDBMS.Conn.RetryMaxDelay = config.DefaultRetryMaxDelay
+	expected.Database.RDBMS.Conn.BatchSize = config.DefaultBatchSize
 	expected.Database.RDBMS.Log.LogLevel = 1
+	// defaults to the name of the service
+	expected.Database.RDBMS.Env.AppName = strings.TrimSpace(os.Getenv("APP_NAME"))
+	expected.Database.RDBMS.Log.SlowThreshold = config.DefaultSlowThreshold
 
 	expected.Database.REDIS.Activate = config.Activated
 	if !config.IsRedis() {
@@ -122,6 +132,10 @@ func TestGetConfig(t *testing.T) {
 	expected.Database.MongoDB.Env.PoolSize = 50
 	expected.Database.MongoDB.Env.PoolMon = "no"
 	expected.Database.MongoDB.Env.ConnTTL = 10
+	expected.Database.MongoDB.Env.MaxRetries = config.DefaultMaxRetries
+	expected.Database.MongoDB.Env.RetryDelay = config.DefaultRetryDelay
+	expected.Database.MongoDB.Env.RetryMaxDelay = config.DefaultRetryMaxDelay
+	expected.Database.MongoDB.Env.EnableServerAPI = true
 
 	expected.EmailConf.Activate = config.Activated
 	if !config.IsEmailService() {
@@ -400,21 +414,61 @@ func TestErrorGetConfig(t *testing.T) {
 		{
 			Key: "DBCONNMAXLIFETIME",
 		},
+		{
+			Key:   "DBCONNMAXIDLETIME",
+			Value: "text",
+		},
+		{
+			Key:   "DBQUERY_TIMEOUT",
+			Value: "text",
+		},
+		{
+			Key:   "DBBATCH_SIZE",
+			Value: "text",
+		},
 		{
 			Key: "DBLOGLEVEL",
 		},
+		{
+			Key:   "DBLOGLEVEL",
+			Value: "0",
+		},
+		{
+			Key:   "DBLOGLEVEL",
+			Value: "5",
+		},
+		{
+			Key:   "DBMAXRETRIES",
+			Value: "text",
+		},
+		{
+			Key:   "DBSLOWTHRESHOLD",
+			Value: "text",
+		},
+		{
+			Key:   "DBRETRYDELAY",
+			Value: "text",
+		},
 		{
 			Key: "POOLSIZE",
 		},
 		{
 			Key: "CONNTTL",
 		},
+		{
+			Key:   "REDISDB",
+			Value: "text",
+		},
 		{
 			Key: "MONGO_POOLSIZE",
 		},
 		{
 			Key: "MONGO_CONNTTL",
 		},
+		{
+			Key:   "MONGO_RETRYMAXDELAY",
+			Value: "text",
+		},
 		{
 			Key: "EMAIL_VERIFY_TEMPLATE_ID",
 		},
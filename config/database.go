@@ -24,11 +24,36 @@ type RDBMS struct {
 		Host     string
 		Port     string
 		TimeZone string
+		Socket   string
+		// ForceUTC - store and read the timestamps in UTC, overrides
+		// TimeZone and Loc, GORM generates the timestamps in UTC
+		ForceUTC bool
+		// AppName - postgres only, application_name of the connections
+		// shown in pg_stat_activity
+		AppName string
+		// Schema - postgres only, schema set as search_path of the
+		// connections, e.g. for schema-per-tenant isolation
+		Schema string
+		// SSHTunnel - mysql and postgres only, connect through an SSH
+		// bastion host, disabled when Host is empty
+		SSHTunnel SSHTunnel
+		// mysql only
+		Charset       string
+		Collation     string
+		Loc           string
+		ParseTime     bool
+		TablePrefix   string
+		SingularTable bool
+		// DisableFKConstraintOnMigrate - do not create foreign key
+		// constraints in AutoMigrate, nil: true for sqlite3, false for
+		// the other drivers
+		DisableFKConstraintOnMigrate *bool
 	}
 	Access struct {
 		DbName string
 		User   string
 		Pass   string
+		URL    string
 	}
 	Ssl struct {
 		Sslmode    string
@@ -42,12 +67,57 @@ type RDBMS struct {
 		MaxIdleConns    int
 		MaxOpenConns    int
 		ConnMaxLifetime time.Duration
+		ConnMaxIdleTime time.Duration
+		// ConnectTimeout - deadline of the initial ping, 0: no deadline
+		// other than the context of InitDBContext
+		ConnectTimeout time.Duration
+		// QueryTimeout - server-side limit of the statements, 0: no
+		// limit, see database.WithQueryTimeout for the other drivers
+		QueryTimeout time.Duration
+		// AcquireTimeout - deadline of database.DBWithAcquireTimeout,
+		// 0: no deadline
+		AcquireTimeout time.Duration
+		MaxRetries     int
+		RetryDelay     time.Duration
+		RetryMaxDelay  time.Duration
+		Compression    string
+		// PreferSimpleProtocol - postgres only, disables the implicit
+		// prepared statements of pgx
+		PreferSimpleProtocol bool
+		// PrepareStmt - cache prepared statements in GORM
+		PrepareStmt bool
+		// SkipDefaultTransaction - do not wrap the single create,
+		// update and delete operations of GORM in a transaction
+		SkipDefaultTransaction bool
+		// WarmUp - number of idle connections opened after InitDB
+		WarmUp int
+		// BatchSize - default number of rows per INSERT of
+		// database.BatchCreate
+		BatchSize int
+	}
+	Replica struct {
+		Hosts []string
+		URLs  []string
 	}
 	Log struct {
-		LogLevel int
+		LogLevel      int
+		SlowThreshold int // milliseconds
+		EnableTracing bool
 	}
 }
 
+// SSHTunnel - SSH host forwarding the connections to the database
+type SSHTunnel struct {
+	// Host - host[:port] of the SSH server, port 22 by default
+	Host string
+	User string
+	// KeyPath - private key of the user, unencrypted
+	KeyPath string
+	// KnownHostsPath - known_hosts file verifying the key of the SSH
+	// server, ~/.ssh/known_hosts by default
+	KnownHostsPath string
+}
+
 // REDIS - redis database variables
 type REDIS struct {
 	Activate string
@@ -55,9 +125,36 @@ type REDIS struct {
 		Host string
 		Port string
 	}
+	Access struct {
+		User string
+		Pass string
+	}
 	Conn struct {
 		PoolSize int
 		ConnTTL  int
+		DB       int
+
+		// zero keeps the defaults of radix: no dial, read and write
+		// timeouts other than the context deadline, no max lifetime
+		DialTimeout  time.Duration
+		ReadTimeout  time.Duration
+		WriteTimeout time.Duration
+		MaxLifetime  time.Duration
+
+		// HealthCheckInterval - interval of the background PING, the
+		// client is recreated when it fails, 0: disabled
+		HealthCheckInterval time.Duration
+
+		UseTLS             bool
+		CACert             string
+		InsecureSkipVerify bool
+	}
+	Sentinel struct {
+		MasterName string
+		Addrs      []string
+	}
+	Cluster struct {
+		Addrs []string
 	}
 }
 
@@ -65,10 +162,32 @@ type REDIS struct {
 type MongoDB struct {
 	Activate string
 	Env      struct {
-		AppName  string
-		URI      string
-		PoolSize uint64
-		PoolMon  string
-		ConnTTL  int
+		AppName      string
+		URI          string
+		DatabaseName string
+		PoolSize     uint64
+		PoolMon      string
+		ConnTTL      int
+
+		MaxRetries    int
+		RetryDelay    time.Duration
+		RetryMaxDelay time.Duration
+
+		// zero keeps the defaults of the driver or the values set in
+		// the URI: 30s connect and server selection timeouts, no socket
+		// timeout
+		ConnectTimeout         time.Duration
+		ServerSelectionTimeout time.Duration
+		SocketTimeout          time.Duration
+
+		// Compressors - wire compression in order of preference:
+		// snappy, zlib, zstd, empty: no compression
+		Compressors []string
+
+		EnableServerAPI bool
+
+		WriteConcern   string
+		ReadConcern    string
+		ReadPreference string
 	}
 }
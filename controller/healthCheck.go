@@ -0,0 +1,18 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/pilinux/gorest/handler"
+	"github.com/pilinux/gorest/lib/renderer"
+)
+
+// HealthCheck - verify that all initialized databases are reachable
+//
+// Suitable for readiness probes, it responds with 503 Service Unavailable
+// when at least one database can not be reached.
+func HealthCheck(c *gin.Context) {
+	resp, statusCode := handler.HealthCheck(c.Request.Context())
+
+	renderer.Render(c, resp, statusCode)
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"github.com/pilinux/gorest/config"
+)
+
+// NotActivatedError - the datastore is deactivated in the config
+// (ACTIVATE_RDBMS, ACTIVATE_REDIS or ACTIVATE_MONGO), use errors.As
+// to tell it apart from a failed initialization
+type NotActivatedError struct {
+	// Datastore - "rdbms", "redis" or "mongo"
+	Datastore string
+}
+
+// Error - implement the error interface
+func (e *NotActivatedError) Error() string {
+	return e.Datastore + " is not activated"
+}
+
+// rdbmsDeactivated - true when cfg is loaded and ACTIVATE_RDBMS is not
+// set to yes
+func rdbmsDeactivated(cfg *config.Configuration) bool {
+	return cfg != nil && cfg.Database.RDBMS.Activate != config.Activated
+}
+
+// redisDeactivated - true when cfg is loaded and ACTIVATE_REDIS is not
+// set to yes
+func redisDeactivated(cfg *config.Configuration) bool {
+	return cfg != nil && cfg.Database.REDIS.Activate != config.Activated
+}
+
+// mongoDeactivated - true when cfg is loaded and ACTIVATE_MONGO is not
+// set to yes
+func mongoDeactivated(cfg *config.Configuration) bool {
+	return cfg != nil && cfg.Database.MongoDB.Activate != config.Activated
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pilinux/gorest/config"
+)
+
+func TestDeactivated(t *testing.T) {
+	// config not loaded
+	if rdbmsDeactivated(nil) || redisDeactivated(nil) || mongoDeactivated(nil) {
+		t.Error("expected no datastore to be deactivated without config")
+	}
+
+	cfg := &config.Configuration{}
+	cfg.Database.RDBMS.Activate = config.Activated
+	if rdbmsDeactivated(cfg) {
+		t.Error("expected rdbms to be activated")
+	}
+	if !redisDeactivated(cfg) {
+		t.Error("expected redis to be deactivated")
+	}
+	if !mongoDeactivated(cfg) {
+		t.Error("expected mongo to be deactivated")
+	}
+}
+
+func TestNotActivatedError(t *testing.T) {
+	err := fmt.Errorf("cache: %w", &NotActivatedError{Datastore: "redis"})
+
+	var notActivated *NotActivatedError
+	if !errors.As(err, &notActivated) {
+		t.Fatal("expected a *NotActivatedError")
+	}
+	if notActivated.Datastore != "redis" {
+		t.Errorf("expected datastore redis, got %s", notActivated.Datastore)
+	}
+	if err.Error() != "cache: redis is not activated" {
+		t.Errorf("unexpected message: %s", err)
+	}
+}
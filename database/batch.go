@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// BatchCreate - insert the records of value, a pointer to a slice of
+// models, with one INSERT statement per batchSize records
+//
+// With batchSize 0, DBBATCH_SIZE is used (default: 100). All batches
+// run in one transaction unless SkipDefaultTransaction is set, a
+// failed batch rolls back the previous ones.
+//
+//	users := []model.User{...}
+//	err := database.BatchCreate(ctx, &users, 0)
+func BatchCreate(ctx context.Context, value interface{}, batchSize int) error {
+	if batchSize < 0 {
+		return errors.New("batch size must be greater than 0")
+	}
+	if batchSize == 0 {
+		batchSize = defaultBatchSize()
+	}
+
+	db := GetDB()
+	if db == nil {
+		return ErrDBNotInitialized
+	}
+
+	return db.WithContext(ctx).CreateInBatches(value, batchSize).Error
+}
+
+// defaultBatchSize - DBBATCH_SIZE, config.DefaultBatchSize when it is
+// not set
+func defaultBatchSize() int {
+	if cfg := config.GetConfig(); cfg != nil && cfg.Database.RDBMS.Conn.BatchSize > 0 {
+		return cfg.Database.RDBMS.Conn.BatchSize
+	}
+
+	return config.DefaultBatchSize
+}
+
+// DeleteByIDs - delete the records of model with the given primary keys
+// and return the number of deleted rows
+//
+// The IDs are deleted with one DELETE ... WHERE id IN (...) statement
+// per chunkSize IDs, so that the number of bind parameters stays below
+// the limit of the driver. With chunkSize 0, a safe size for the
+// driver is used. All chunks run in one transaction unless
+// SkipDefaultTransaction is set. Models with gorm.DeletedAt are soft
+// deleted.
+//
+//	n, err := database.DeleteByIDs(ctx, &model.Post{}, ids, 0)
+func DeleteByIDs(ctx context.Context, model interface{}, ids []interface{}, chunkSize int) (int64, error) {
+	if chunkSize < 0 {
+		return 0, errors.New("chunk size must be greater than 0")
+	}
+
+	db := GetDB()
+	if db == nil {
+		return 0, ErrDBNotInitialized
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if chunkSize == 0 {
+		chunkSize = deleteChunkSize(db.Dialector.Name())
+	}
+
+	var deleted int64
+	deleteChunks := func(tx *gorm.DB) error {
+		for start := 0; start < len(ids); start += chunkSize {
+			chunk := ids[start:min(start+chunkSize, len(ids))]
+			result := tx.Where(clause.IN{Column: clause.PrimaryColumn, Values: chunk}).Delete(model)
+			if result.Error != nil {
+				return result.Error
+			}
+			deleted += result.RowsAffected
+		}
+		return nil
+	}
+
+	db = db.WithContext(ctx)
+	if db.SkipDefaultTransaction {
+		// the chunks deleted before an error are kept
+		err := deleteChunks(db)
+		return deleted, err
+	}
+	if err := runTransaction(db, nil, deleteChunks); err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// deleteChunkSize - IDs per DELETE of DeleteByIDs, below the bind
+// parameter limit of the driver
+func deleteChunkSize(driver string) int {
+	switch driver {
+	case "sqlite":
+		// the dialector of sqlite3, SQLITE_MAX_VARIABLE_NUMBER is 999
+		// before sqlite 3.32
+		return 500
+	case DriverSQLServer:
+		// 2100 parameters per request
+		return 2000
+	case DriverPostgres, DriverMySQL:
+		// 65535 parameters per statement
+		return 10000
+	default:
+		return 1000
+	}
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type batchItem struct {
+	ID   uint
+	Name string
+}
+
+func TestBatchCreate(t *testing.T) {
+	_ = CloseDB()
+	if err := BatchCreate(context.Background(), &[]batchItem{{}}, 0); !errors.Is(err, ErrDBNotInitialized) {
+		t.Errorf("expected ErrDBNotInitialized, got %v", err)
+	}
+
+	db, err := InitTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+	if err := db.AutoMigrate(&batchItem{}); err != nil {
+		t.Fatal(err)
+	}
+
+	items := make([]batchItem, 250)
+	for i := range items {
+		items[i].Name = fmt.Sprintf("item-%d", i)
+	}
+	if err := BatchCreate(context.Background(), &items, 0); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	if err := db.Model(&batchItem{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 250 {
+		t.Errorf("expected 250 rows, got %d", count)
+	}
+	if items[249].ID == 0 {
+		t.Error("expected the primary keys to be set")
+	}
+
+	if err := BatchCreate(context.Background(), &items, -1); err == nil {
+		t.Error("expected error for a negative batch size")
+	}
+}
+
+// BenchmarkBatchCreate - inserting 1000 rows one by one and in batches
+func BenchmarkBatchCreate(b *testing.B) {
+	const rows = 1000
+
+	for _, batchSize := range []int{1, 10, 100, 500} {
+		b.Run(fmt.Sprintf("batch=%d", batchSize), func(b *testing.B) {
+			db, err := InitTestDB()
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer CloseDB()
+			if err := db.AutoMigrate(&batchItem{}); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				items := make([]batchItem, rows)
+				for j := range items {
+					items[j].Name = "item"
+				}
+				if err := BatchCreate(context.Background(), &items, batchSize); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteByIDs(t *testing.T) {
+	_ = CloseDB()
+	if _, err := DeleteByIDs(context.Background(), &batchItem{}, []interface{}{1}, 0); !errors.Is(err, ErrDBNotInitialized) {
+		t.Errorf("expected ErrDBNotInitialized, got %v", err)
+	}
+
+	db, err := InitTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+	if err := db.AutoMigrate(&batchItem{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// more IDs than bind parameters allowed by sqlite in one statement
+	const rows = 40000
+	items := make([]batchItem, rows)
+	for i := range items {
+		items[i].Name = "item"
+	}
+	if err := BatchCreate(context.Background(), &items, 1000); err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]interface{}, 0, rows)
+	for _, item := range items[:rows-1] {
+		ids = append(ids, item.ID)
+	}
+	// unknown IDs are ignored
+	ids = append(ids, rows+1)
+
+	deleted, err := DeleteByIDs(context.Background(), &batchItem{}, ids, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != rows-1 {
+		t.Errorf("expected %d deleted rows, got %d", rows-1, deleted)
+	}
+	var remaining []batchItem
+	if err := db.Find(&remaining).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != items[rows-1].ID {
+		t.Errorf("expected the last row to remain, got %v", remaining)
+	}
+
+	if deleted, err := DeleteByIDs(context.Background(), &batchItem{}, nil, 0); err != nil || deleted != 0 {
+		t.Errorf("expected no deleted row, got %d: %v", deleted, err)
+	}
+	if _, err := DeleteByIDs(context.Background(), &batchItem{}, ids, -1); err == nil {
+		t.Error("expected error for a negative chunk size")
+	}
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/mediocregopher/radix/v4"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// ErrRedisNotInitialized - redis has not been initialized
+var ErrRedisNotInitialized = errors.New("redis is not initialized")
+
+// CacheKeyPrefix - prefix added to all keys of the cache helpers,
+// e.g. "myapp:" to share a redis instance between applications
+var CacheKeyPrefix string
+
+// cacheKey - key stored in redis
+func cacheKey(key string) string {
+	return CacheKeyPrefix + key
+}
+
+// cacheClient - the redis client used by the cache helpers
+func cacheClient() (radix.Client, error) {
+	client := currentRedis()
+	if client == nil || *client == nil {
+		if redisDeactivated(config.GetConfig()) {
+			return nil, &NotActivatedError{Datastore: "redis"}
+		}
+		return nil, ErrRedisNotInitialized
+	}
+
+	return *client, nil
+}
+
+// redisMillis - ttl in milliseconds for PX, rounded up as PX 0 is
+// rejected by redis
+func redisMillis(ttl time.Duration) string {
+	ms := (ttl + time.Millisecond - 1) / time.Millisecond
+	return strconv.FormatInt(int64(ms), 10)
+}
+
+// CacheSet - store val under key
+//
+// The key expires after ttl (millisecond precision). With ttl <= 0,
+// the key does not expire.
+func CacheSet(ctx context.Context, key string, val []byte, ttl time.Duration) error {
+	client, err := cacheClient()
+	if err != nil {
+		return err
+	}
+
+	args := []string{cacheKey(key), string(val)}
+	if ttl > 0 {
+		args = append(args, "PX", redisMillis(ttl))
+	}
+
+	return client.Do(ctx, radix.Cmd(nil, "SET", args...))
+}
+
+// CacheGet - get the value stored under key
+//
+// ok is false when the key does not exist, err is only set when redis
+// could not be queried.
+func CacheGet(ctx context.Context, key string) (val []byte, ok bool, err error) {
+	client, err := cacheClient()
+	if err != nil {
+		return nil, false, err
+	}
+
+	mb := radix.Maybe{Rcv: &val}
+	if err = client.Do(ctx, radix.Cmd(&mb, "GET", cacheKey(key))); err != nil {
+		return nil, false, err
+	}
+	if mb.Null {
+		return nil, false, nil
+	}
+	if val == nil {
+		// empty value
+		val = []byte{}
+	}
+
+	return val, true, nil
+}
+
+// CacheDel - delete the keys, missing keys are ignored
+//
+// In cluster mode, all keys must hash to the same slot.
+func CacheDel(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	client, err := cacheClient()
+	if err != nil {
+		return err
+	}
+
+	args := make([]string, len(keys))
+	for i, key := range keys {
+		args[i] = cacheKey(key)
+	}
+
+	return client.Do(ctx, radix.Cmd(nil, "DEL", args...))
+}
+
+// CacheSetJSON - store the JSON encoding of v under key
+//
+// See CacheSet for ttl.
+func CacheSetJSON(ctx context.Context, key string, v interface{}, ttl time.Duration) error {
+	val, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return CacheSet(ctx, key, val, ttl)
+}
+
+// CacheGetJSON - decode the JSON value stored under key into dest
+//
+// ok is false when the key does not exist, dest is not modified then.
+func CacheGetJSON(ctx context.Context, key string, dest interface{}) (ok bool, err error) {
+	val, ok, err := CacheGet(ctx, key)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if err = json.Unmarshal(val, dest); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
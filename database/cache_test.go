@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mediocregopher/radix/v4"
+)
+
+// initTestRedis - point the redis client of the package to miniredis
+func initTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+
+	s := miniredis.RunT(t)
+	client, err := (radix.PoolConfig{Size: 1}).New(context.Background(), "tcp", s.Addr())
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+
+	previous := redisClient
+	redisClient = &client
+	t.Cleanup(func() {
+		redisClient = previous
+		_ = client.Close()
+	})
+
+	return s
+}
+
+func TestCache(t *testing.T) {
+	s := initTestRedis(t)
+	ctx := context.Background()
+
+	if err := CacheSet(ctx, "k1", []byte("v1"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := CacheSet(ctx, "k2", []byte{}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	val, ok, err := CacheGet(ctx, "k1")
+	if err != nil || !ok || string(val) != "v1" {
+		t.Errorf("expected v1, got %q, %v, %v", val, ok, err)
+	}
+	if ttl := s.TTL("k1"); ttl != time.Minute {
+		t.Errorf("expected ttl 1m, got %v", ttl)
+	}
+
+	val, ok, err = CacheGet(ctx, "k2")
+	if err != nil || !ok || val == nil || len(val) != 0 {
+		t.Errorf("expected empty hit, got %q, %v, %v", val, ok, err)
+	}
+	if ttl := s.TTL("k2"); ttl != 0 {
+		t.Errorf("expected no ttl, got %v", ttl)
+	}
+
+	// miss is not an error
+	val, ok, err = CacheGet(ctx, "missing")
+	if err != nil || ok || val != nil {
+		t.Errorf("expected miss, got %q, %v, %v", val, ok, err)
+	}
+
+	// expiry
+	s.FastForward(2 * time.Minute)
+	if _, ok, _ = CacheGet(ctx, "k1"); ok {
+		t.Error("expected k1 to expire")
+	}
+
+	if err := CacheDel(ctx, "k2", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if s.Exists("k2") {
+		t.Error("expected k2 to be deleted")
+	}
+}
+
+func TestCacheKeyPrefix(t *testing.T) {
+	s := initTestRedis(t)
+	CacheKeyPrefix = "app:"
+	defer func() { CacheKeyPrefix = "" }()
+
+	if err := CacheSet(context.Background(), "k", []byte("v"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Exists("app:k") {
+		t.Error("expected key app:k")
+	}
+}
+
+func TestCacheNotInitialized(t *testing.T) {
+	previous := redisClient
+	redisClient = nil
+	defer func() { redisClient = previous }()
+
+	if _, _, err := CacheGet(context.Background(), "k"); !errors.Is(err, ErrRedisNotInitialized) {
+		t.Errorf("expected ErrRedisNotInitialized, got %v", err)
+	}
+}
+
+func TestCacheJSON(t *testing.T) {
+	s := initTestRedis(t)
+	ctx := context.Background()
+
+	type address struct {
+		City string   `json:"city"`
+		Tags []string `json:"tags"`
+	}
+	type user struct {
+		ID      uint              `json:"id"`
+		Name    string            `json:"name"`
+		Address *address          `json:"address"`
+		Meta    map[string]string `json:"meta"`
+	}
+
+	in := user{
+		ID:      1,
+		Name:    "gorest",
+		Address: &address{City: "Berlin", Tags: []string{"a", "b"}},
+		Meta:    map[string]string{"k": "v"},
+	}
+	if err := CacheSetJSON(ctx, "user:1", in, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	var out user
+	ok, err := CacheGetJSON(ctx, "user:1", &out)
+	if err != nil || !ok {
+		t.Fatalf("expected hit, got %v, %v", ok, err)
+	}
+	if out.ID != in.ID || out.Name != in.Name || out.Address == nil ||
+		out.Address.City != "Berlin" || len(out.Address.Tags) != 2 || out.Meta["k"] != "v" {
+		t.Errorf("unexpected value: %+v", out)
+	}
+
+	// ttl
+	s.FastForward(2 * time.Second)
+	out = user{}
+	ok, err = CacheGetJSON(ctx, "user:1", &out)
+	if err != nil || ok {
+		t.Errorf("expected miss after ttl, got %v, %v", ok, err)
+	}
+	if out.ID != 0 {
+		t.Error("expected dest not to be modified on miss")
+	}
+
+	// not JSON
+	if err := CacheSet(ctx, "raw", []byte("not json"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CacheGetJSON(ctx, "raw", &out); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+
+	if err := CacheSetJSON(ctx, "chan", make(chan int), 0); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
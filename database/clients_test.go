@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mediocregopher/radix/v4"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// TestConcurrentInitAndGet initializes, reads and closes the clients
+// concurrently, run it with `go test -race`
+func TestConcurrentInitAndGet(t *testing.T) {
+	cfg := config.RDBMS{}
+	cfg.Env.Driver = "sqlite3"
+	cfg.Access.DbName = filepath.Join(t.TempDir(), "concurrent.db")
+
+	s := miniredis.RunT(t)
+
+	const n = 8
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(4)
+
+		// init
+		go func() {
+			defer wg.Done()
+			if _, err := InitNamedDB(DefaultDBName, cfg); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			client, err := (radix.PoolConfig{Size: 1}).New(context.Background(), "tcp", s.Addr())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			previous := currentRedis()
+			setRedis(&client)
+			if previous != nil {
+				_ = (*previous).Close()
+			}
+		}()
+
+		// get
+		go func() {
+			defer wg.Done()
+			if db := GetDB(); db != nil {
+				_ = db.Exec("SELECT 1").Error
+			}
+			_ = GetRedisAddr()
+			_, _, _ = CacheGet(context.Background(), "key")
+			_ = GetMongo()
+		}()
+		go func() {
+			defer wg.Done()
+			_ = HealthCheck(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if GetDB() == nil {
+		t.Error("expected the default connection to be set")
+	}
+	if GetRedis() == nil {
+		t.Error("expected the redis client to be set")
+	}
+
+	if err := CloseDB(); err != nil {
+		t.Error(err)
+	}
+	if err := CloseRedis(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLazyInitDisabled(t *testing.T) {
+	_ = CloseDB()
+	_ = CloseRedis()
+
+	LazyInit = false
+	if GetDB() != nil || GetRedis() != nil || GetMongo() != nil {
+		t.Error("expected nil clients without LazyInit")
+	}
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// cockroachTxAttempts - attempts of the transactions of a CockroachDB
+// connection, which are retried after a serialization failure
+const cockroachTxAttempts = 5
+
+// cockroachPlugin - marks the connections to CockroachDB, which use
+// the dialector of postgres
+type cockroachPlugin struct{}
+
+// Name - name of the plugin
+func (cockroachPlugin) Name() string {
+	return "gorest:cockroachdb"
+}
+
+// Initialize - nothing to register, the plugin is only a marker
+func (cockroachPlugin) Initialize(*gorm.DB) error {
+	return nil
+}
+
+// isCockroachDB - whether db is connected to CockroachDB
+func isCockroachDB(db *gorm.DB) bool {
+	if db == nil || db.Config == nil {
+		return false
+	}
+	_, ok := db.Config.Plugins[cockroachPlugin{}.Name()]
+
+	return ok
+}
+
+// isPostgresDriver - whether driver speaks the wire protocol of
+// postgres and is connected with pgx
+func isPostgresDriver(driver string) bool {
+	return driver == DriverPostgres || driver == DriverCockroachDB
+}
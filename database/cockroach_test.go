@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestCockroachDBTransactionRetry(t *testing.T) {
+	db, err := InitTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+
+	if isCockroachDB(db) {
+		t.Fatal("expected sqlite not to be cockroachdb")
+	}
+	attempts := 0
+	serializationFailure := &pgconn.PgError{Code: "40001"}
+	fail := func(*gorm.DB) error {
+		attempts++
+		return serializationFailure
+	}
+	if err := WithTransaction(context.Background(), fail); !errors.Is(err, serializationFailure) || attempts != 1 {
+		t.Errorf("expected a single attempt, got %d: %v", attempts, err)
+	}
+
+	// mark the connection like openDB does for cockroachdb
+	if err := db.Use(cockroachPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := Driver(); got != DriverCockroachDB {
+		t.Errorf("expected driver %s, got %s", DriverCockroachDB, got)
+	}
+
+	attempts = 0
+	if err := WithTransaction(context.Background(), fail); !errors.Is(err, serializationFailure) || attempts != cockroachTxAttempts {
+		t.Errorf("expected %d attempts, got %d: %v", cockroachTxAttempts, attempts, err)
+	}
+	attempts = 0
+	if err := WithReadOnlyTransaction(context.Background(), fail); !errors.Is(err, serializationFailure) || attempts != cockroachTxAttempts {
+		t.Errorf("expected %d attempts, got %d: %v", cockroachTxAttempts, attempts, err)
+	}
+	attempts = 0
+	if err := WithRetryableTransaction(context.Background(), 7, fail); !errors.Is(err, serializationFailure) || attempts != 7 {
+		t.Errorf("expected 7 attempts, got %d: %v", attempts, err)
+	}
+
+	// other errors are not retried
+	attempts = 0
+	errFn := errors.New("fn failed")
+	err = WithTransaction(context.Background(), func(*gorm.DB) error {
+		attempts++
+		return errFn
+	})
+	if !errors.Is(err, errFn) || attempts != 1 {
+		t.Errorf("expected a single attempt, got %d: %v", attempts, err)
+	}
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/pilinux/gorest/lib/middleware"
+)
+
+// FromContext - the default connection bound to ctx, nil when the
+// database is not initialized
+//
+// The queries are cancelled when ctx is done, e.g. when the client
+// disconnects, and the trace spans of ctx are propagated to the
+// queries. In a controller, pass the context of the request:
+//
+//	func GetUser(c *gin.Context) {
+//		db := database.FromContext(c.Request.Context())
+//		err := db.First(&user, c.Param("id")).Error
+//		...
+//	}
+//
+// With middleware.DBContext in the chain, the *gin.Context can be
+// passed directly: database.FromContext(c).
+func FromContext(ctx context.Context) *gorm.DB {
+	db := GetDB()
+	if db == nil || ctx == nil {
+		return db
+	}
+
+	return db.WithContext(requestContext(ctx))
+}
+
+// requestContext - the context of the request stored by
+// middleware.DBContext, ctx itself otherwise
+func requestContext(ctx context.Context) context.Context {
+	// *gin.Context is not cancelled with the request unless
+	// ContextWithFallback is enabled
+	if reqCtx, ok := ctx.Value(middleware.RequestContextKey).(context.Context); ok {
+		return reqCtx
+	}
+
+	return ctx
+}
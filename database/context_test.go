@@ -0,0 +1,37 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pilinux/gorest/lib/middleware"
+)
+
+func TestFromContext(t *testing.T) {
+	_ = CloseDB()
+	if db := FromContext(context.Background()); db != nil {
+		t.Error("expected nil without database")
+	}
+
+	db := initTxTestDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	if got := FromContext(ctx); got.Statement.Context != ctx {
+		t.Error("expected the connection to be bound to ctx")
+	}
+
+	// stashed request context, e.g. in a *gin.Context
+	outer := context.WithValue(context.Background(), middleware.RequestContextKey, ctx)
+	if got := FromContext(outer); got.Statement.Context != ctx {
+		t.Error("expected the connection to be bound to the request context")
+	}
+
+	cancel()
+	err := FromContext(ctx).Create(&txItem{Name: "cancelled"}).Error
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if n := countTxItems(t, db); n != 0 {
+		t.Errorf("expected no item, got %d", n)
+	}
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// CredentialProvider - source of the user and the password of a
+// relational database, e.g. Vault or AWS Secrets Manager
+//
+// The credentials replace DBUSER and DBPASS of the config. They are
+// fetched each time the connection pool is opened, so ReconnectDB
+// picks up rotated secrets.
+type CredentialProvider interface {
+	GetCredentials(ctx context.Context) (user, pass string, err error)
+}
+
+// ConfigCredentialProvider - CredentialProvider returning the
+// credentials of the config, the behavior without provider
+type ConfigCredentialProvider struct {
+	Config config.RDBMS
+}
+
+// GetCredentials - DBUSER and DBPASS of the config
+func (p ConfigCredentialProvider) GetCredentials(context.Context) (user, pass string, err error) {
+	return p.Config.Access.User, p.Config.Access.Pass, nil
+}
+
+// providers of SetCredentialProvider and SetPasswordProvider
+var (
+	credentialProviderMu sync.RWMutex
+	credentialProvider   CredentialProvider
+	passwordProvider     func(ctx context.Context) (string, error)
+)
+
+// PasswordMaxLifetime - max lifetime of the connections opened with a
+// password provider, shorter than the validity of the tokens, e.g. 15
+// minutes for AWS RDS IAM authentication
+var PasswordMaxLifetime = 14 * time.Minute
+
+// SetCredentialProvider - fetch the credentials of the default
+// connection with p when InitDB, InitAll or ReconnectDB open it, nil
+// restores the credentials of the config
+//
+// Set the provider before the initialization:
+//
+//	database.SetCredentialProvider(vaultProvider)
+//	db, err := database.InitDB()
+func SetCredentialProvider(p CredentialProvider) {
+	credentialProviderMu.Lock()
+	defer credentialProviderMu.Unlock()
+
+	credentialProvider = p
+}
+
+// registeredCredentialProvider - provider of SetCredentialProvider
+func registeredCredentialProvider() CredentialProvider {
+	credentialProviderMu.RLock()
+	defer credentialProviderMu.RUnlock()
+
+	return credentialProvider
+}
+
+// SetPasswordProvider - generate the password of each new connection
+// of the default connection pool with fn, e.g. a short-lived AWS RDS
+// IAM authentication token, nil restores the password of the config
+//
+// Only supported by mysql and postgres. The connections are recycled
+// after PasswordMaxLifetime, or after DBCONNMAXLIFETIME when shorter.
+// fn also generates the password of the connections to the read
+// replicas.
+// mysql requires allowCleartextPasswords=true in the DSN for RDS IAM
+// authentication.
+//
+//	database.SetPasswordProvider(func(ctx context.Context) (string, error) {
+//		return auth.BuildAuthToken(ctx, endpoint, region, user, creds)
+//	})
+func SetPasswordProvider(fn func(ctx context.Context) (string, error)) {
+	credentialProviderMu.Lock()
+	defer credentialProviderMu.Unlock()
+
+	passwordProvider = fn
+}
+
+// registeredPasswordProvider - provider of SetPasswordProvider
+func registeredPasswordProvider() func(ctx context.Context) (string, error) {
+	credentialProviderMu.RLock()
+	defer credentialProviderMu.RUnlock()
+
+	return passwordProvider
+}
+
+// newConnPassword - password of a new connection generated by fn
+func newConnPassword(ctx context.Context, fn func(ctx context.Context) (string, error)) (string, error) {
+	pass, err := fn(ctx)
+	if err != nil {
+		return "", fmt.Errorf("password provider: %w", err)
+	}
+
+	return pass, nil
+}
+
+// applyCredentials - configureDB with the credentials of p
+func applyCredentials(ctx context.Context, configureDB config.RDBMS, p CredentialProvider) (config.RDBMS, error) {
+	user, pass, err := p.GetCredentials(ctx)
+	if err != nil {
+		return configureDB, fmt.Errorf("failed to get the credentials: %w", err)
+	}
+	configureDB.Access.User = user
+	configureDB.Access.Pass = pass
+
+	return configureDB, nil
+}
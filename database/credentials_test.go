@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// funcCredentialProvider - CredentialProvider calling a function
+type funcCredentialProvider func(ctx context.Context) (string, string, error)
+
+func (f funcCredentialProvider) GetCredentials(ctx context.Context) (string, string, error) {
+	return f(ctx)
+}
+
+func TestApplyCredentials(t *testing.T) {
+	configureDB := config.RDBMS{}
+	configureDB.Access.User = "static"
+	configureDB.Access.Pass = "static"
+
+	got, err := applyCredentials(context.Background(), configureDB, ConfigCredentialProvider{Config: configureDB})
+	if err != nil || got.Access.User != "static" || got.Access.Pass != "static" {
+		t.Errorf("expected the credentials of the config, got %+v: %v", got.Access, err)
+	}
+
+	rotated := funcCredentialProvider(func(context.Context) (string, string, error) {
+		return "vault", "s3cret", nil
+	})
+	got, err = applyCredentials(context.Background(), configureDB, rotated)
+	if err != nil || got.Access.User != "vault" || got.Access.Pass != "s3cret" {
+		t.Errorf("expected the credentials of the provider, got %+v: %v", got.Access, err)
+	}
+	if configureDB.Access.User != "static" {
+		t.Error("expected the config to be left untouched")
+	}
+}
+
+func TestSetCredentialProvider(t *testing.T) {
+	defer SetCredentialProvider(nil)
+	defer CloseDB()
+
+	configureDB := config.RDBMS{}
+	configureDB.Env.Driver = DriverSQLite
+	configureDB.Access.DbName = filepath.Join(t.TempDir(), "app.db")
+
+	calls := 0
+	SetCredentialProvider(funcCredentialProvider(func(context.Context) (string, string, error) {
+		calls++
+		return "user", "pass", nil
+	}))
+	if _, err := initDB(context.Background(), configureDB); err != nil {
+		t.Fatal(err)
+	}
+	if err := reconnectDB(configureDB); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("expected the provider to be called by each open, got %d", calls)
+	}
+
+	failed := errors.New("vault is sealed")
+	SetCredentialProvider(funcCredentialProvider(func(context.Context) (string, string, error) {
+		return "", "", failed
+	}))
+	_, err := initDB(context.Background(), configureDB)
+	if !errors.Is(err, failed) || !strings.Contains(err.Error(), "error code: 169") {
+		t.Errorf("expected error code 169, got %v", err)
+	}
+}
+
+func TestPasswordProvider(t *testing.T) {
+	failed := errors.New("token expired")
+	calls := 0
+	provider := func(context.Context) (string, error) {
+		calls++
+		return "", failed
+	}
+
+	for _, driver := range []string{DriverMySQL, DriverPostgres} {
+		t.Run(driver, func(t *testing.T) {
+			calls = 0
+			configureDB := config.RDBMS{}
+			configureDB.Env.Driver = driver
+			configureDB.Env.Host = "127.0.0.1"
+			configureDB.Env.Port = "1"
+			configureDB.Access.User = "user"
+			configureDB.Access.DbName = "app"
+
+			_, err := openDB(context.Background(), configureDB, WithPasswordProvider(provider))
+			if err == nil || !strings.Contains(err.Error(), "password provider: token expired") {
+				t.Errorf("expected the error of the provider, got %v", err)
+			}
+			if calls == 0 {
+				t.Error("expected the provider to be called before connecting")
+			}
+		})
+	}
+
+	configureDB := config.RDBMS{}
+	configureDB.Env.Driver = DriverSQLite
+	configureDB.Access.DbName = filepath.Join(t.TempDir(), "app.db")
+	_, err := openDB(context.Background(), configureDB, WithPasswordProvider(provider))
+	if err == nil || !strings.Contains(err.Error(), "error code: 169") {
+		t.Errorf("expected error code 169, got %v", err)
+	}
+}
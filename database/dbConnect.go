@@ -4,27 +4,49 @@ package database
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/pilinux/gorest/config"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
 
 	// Import MySQL database driver
 	// _ "github.com/jinzhu/gorm/dialects/mysql"
+	gomysql "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 
 	// Import PostgreSQL database driver
 	// _ "github.com/jinzhu/gorm/dialects/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 
 	// Import SQLite3 database driver
 	// _ "github.com/jinzhu/gorm/dialects/sqlite"
 	"gorm.io/driver/sqlite"
 
+	// Import SQL Server database driver
+	"gorm.io/driver/sqlserver"
+
+	// Import ClickHouse database driver
+	"gorm.io/driver/clickhouse"
+
 	// Import Redis Driver
 	"github.com/mediocregopher/radix/v4"
 
@@ -40,237 +62,1724 @@ import (
 // RecordNotFound record not found error message
 const RecordNotFound string = "record not found"
 
-// dbClient variable to access gorm
+// dbClient variable to access gorm, guarded by dbRegistryMu
 var dbClient *gorm.DB
 
-var sqlDB *sql.DB
-var err error
-
-// redisClient variable to access the redis client
+// redisClient variable to access the redis client, guarded by redisMu
 var redisClient *radix.Client
 
+// redisMu - guard of redisClient
+var redisMu sync.RWMutex
+
 // RedisConnTTL - context deadline in second
 var RedisConnTTL int
 
-// mongoClient instance
+// MaxRedisDB - highest logical database index of a default redis server
+const MaxRedisDB int = 15
+
+// mongoClient instance, guarded by mongoMu
 var mongoClient *qmgo.Client
 
+// mongoMu - guard of mongoClient
+var mongoMu sync.RWMutex
+
+// LazyInit - when true, GetDB, GetRedis and GetMongo initialize the
+// client on the first call if the database is activated in the config
+// and the client has not been initialized yet
+//
+// Disabled by default, the clients are initialized explicitly at the
+// startup with InitDB, InitRedis and InitMongo.
+var LazyInit bool
+
+// serialize the lazy initializations, so that concurrent callers do
+// not open several clients
+var (
+	lazyDBMu    sync.Mutex
+	lazyRedisMu sync.Mutex
+	lazyMongoMu sync.Mutex
+)
+
 // InitDB - function to initialize db
-func InitDB() *gorm.DB {
-	var db = dbClient
+//
+// The connection is registered as the default named connection.
+//
+// Successful connections to the databases are logged at Info level,
+// they are hidden when the level of logrus is raised, e.g. with
+// log.SetLevel(log.WarnLevel).
+//
+// When ACTIVATE_RDBMS is not set to yes, InitDB does nothing and
+// returns a nil *gorm.DB without error.
+func InitDB() (*gorm.DB, error) {
+	return InitDBContext(context.Background())
+}
+
+// InitDBContext - initialize db, the connection attempts and the
+// retries are aborted when ctx is done
+//
+// ctx is only used during the startup, the returned *gorm.DB is not
+// bound to it. Use db.WithContext for a per-request context.
+func InitDBContext(ctx context.Context) (*gorm.DB, error) {
+	if rdbmsDeactivated(config.GetConfig()) {
+		log.Info("database: RDBMS is not activated, skipping initialization")
+		return nil, nil
+	}
 
-	configureDB := config.GetConfig().Database.RDBMS
+	return InitDBWithOptionsContext(ctx)
+}
+
+// initDB - open the relational database described by configureDB and
+// register it as the default connection
+//
+// The DSN of DSNEnvVar and the provider of SetCredentialProvider are
+// applied before opts.
+func initDB(ctx context.Context, configureDB config.RDBMS, opts ...DBOption) (*gorm.DB, error) {
+	db, err := openDB(ctx, configureDB, append(defaultConnOptions(), opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	warmUpDB(ctx, db, configureDB.Conn.WarmUp, maxIdleConnsOf(configureDB))
+	acquireTimeout.Store(int64(configureDB.Conn.AcquireTimeout))
+
+	dbRegistryMu.Lock()
+	dbClient = db
+	dbRegistry[DefaultDBName] = db
+	dbRegistryMu.Unlock()
+
+	if version, err := ServerVersion(ctx); err == nil {
+		log.WithFields(log.Fields{
+			"driver":  configureDB.Env.Driver,
+			"version": version,
+		}).Info("database server version")
+	} else {
+		log.WithError(err).Warn("database: failed to query the server version")
+	}
+
+	return db, nil
+}
+
+// openDB - open a new connection pool to the relational database
+// described by configureDB, the options are applied on top of it
+//
+// The plugins registered with RegisterPlugin and then the plugins of
+// the options are applied after the connection is opened.
+func openDB(ctx context.Context, configureDB config.RDBMS, opts ...DBOption) (*gorm.DB, error) {
+	var db *gorm.DB
+	var sqlDB *sql.DB
+	var err error
+
+	o := dbOptions{config: configureDB}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	configureDB = o.config
+	if o.credentials != nil {
+		configureDB, err = applyCredentials(ctx, configureDB, o.credentials)
+		if err != nil {
+			return nil, fmt.Errorf("error code: 169: %w", err)
+		}
+	}
+	if o.dsn != "" {
+		// the DSN replaces the connection settings of the config
+		configureDB.Access.URL = ""
+		if !slices.Contains(supportedDrivers, configureDB.Env.Driver) {
+			return nil, errors.New("error code: 164: the driver " + configureDB.Env.Driver +
+				" is not supported, supported drivers: " + strings.Join(supportedDrivers, ", "))
+		}
+	} else if err = validateRDBMSConfig(configureDB); err != nil {
+		return nil, fmt.Errorf("error code: 164: %w", err)
+	}
+	configureDB = withRecommendedPool(configureDB)
+	configureDB = withForceUTC(configureDB)
+	if configureDB.Env.ForceUTC && o.nowFunc == nil {
+		o.nowFunc = func() time.Time {
+			return time.Now().UTC()
+		}
+	}
 
 	driver := configureDB.Env.Driver
-	username := configureDB.Access.User
 	password := configureDB.Access.Pass
 	database := configureDB.Access.DbName
 	host := configureDB.Env.Host
-	port := configureDB.Env.Port
+	socket := configureDB.Env.Socket
 	sslmode := configureDB.Ssl.Sslmode
-	timeZone := configureDB.Env.TimeZone
 	maxIdleConns := configureDB.Conn.MaxIdleConns
 	maxOpenConns := configureDB.Conn.MaxOpenConns
 	connMaxLifetime := configureDB.Conn.ConnMaxLifetime
+	connMaxIdleTime := configureDB.Conn.ConnMaxIdleTime
+	connectTimeout := configureDB.Conn.ConnectTimeout
+	maxRetries := configureDB.Conn.MaxRetries
+	retryDelay := configureDB.Conn.RetryDelay
+	retryMaxDelay := configureDB.Conn.RetryMaxDelay
 	logLevel := configureDB.Log.LogLevel
+	slowThreshold := time.Duration(configureDB.Log.SlowThreshold) * time.Millisecond
+	if slowThreshold <= 0 {
+		slowThreshold = DefaultSlowThreshold
+	}
+	var gormLogger logger.Interface = newGormLogger(gormLogLevel(logLevel), slowThreshold)
+	if o.logger != nil {
+		gormLogger = o.logger
+	}
+	// changing the prefix or the pluralization after the tables were
+	// migrated requires a migration renaming the existing tables
+	namingStrategy := schema.NamingStrategy{
+		TablePrefix:   configureDB.Env.TablePrefix,
+		SingularTable: configureDB.Env.SingularTable,
+	}
+	// foreign key constraints are not created by AutoMigrate for
+	// sqlite3 and cockroachdb unless configured otherwise, cockroachdb
+	// rejects the constraints on tables created in the same migration
+	disableFKConstraint := driver == DriverSQLite || driver == DriverCockroachDB
+	if configureDB.Env.DisableFKConstraintOnMigrate != nil {
+		disableFKConstraint = *configureDB.Env.DisableFKConstraintOnMigrate
+	}
+	connURL := configureDB.Access.URL
+
+	if connURL != "" {
+		if err = validateURLScheme(driver, connURL); err != nil {
+			return nil, fmt.Errorf("error code: 158: %w", err)
+		}
+	}
+
+	if socket != "" && (driver == DriverMySQL || isPostgresDriver(driver)) && connURL == "" && o.dsn == "" {
+		if err = validateSocket(driver, socket); err != nil {
+			return nil, fmt.Errorf("error code: 166: %w", err)
+		}
+	}
+
+	if o.password != nil {
+		if driver != DriverMySQL && !isPostgresDriver(driver) {
+			return nil, errors.New("error code: 169: password provider: only supported by mysql, postgres and cockroachdb")
+		}
+		// recycle the connections before the password expires
+		if connMaxLifetime <= 0 || connMaxLifetime > PasswordMaxLifetime {
+			connMaxLifetime = PasswordMaxLifetime
+		}
+	}
+
+	var tunnel *sshTunnel
+	if configureDB.Env.SSHTunnel.Host != "" {
+		if driver != DriverMySQL && !isPostgresDriver(driver) {
+			return nil, errors.New("error code: 168: ssh tunnel: only supported by mysql, postgres and cockroachdb")
+		}
+		if socket != "" && connURL == "" && o.dsn == "" {
+			return nil, errors.New("error code: 168: ssh tunnel: DBSOCKET is not supported")
+		}
+		tunnel, err = getSSHTunnel(configureDB.Env.SSHTunnel)
+		if err != nil {
+			return nil, fmt.Errorf("error code: 168: %w", err)
+		}
+	}
 
 	switch driver {
-	case "mysql":
-		address := host
-		if port != "" {
-			address += ":" + port
-		}
-		dsn := username + ":" + password + "@tcp(" + address + ")/" + database + "?charset=utf8mb4&parseTime=True&loc=Local"
-		if sslmode == "" {
-			sslmode = "disable"
-		}
-		if sslmode != "disable" {
-			// use host machine's root CAs to verify
-			if sslmode == "require" {
-				dsn += "&tls=true"
+	case DriverMySQL:
+		var mysqlConfig *gomysql.Config
+		if o.dsn != "" {
+			// the parser errors do not contain the password
+			mysqlConfig, err = gomysql.ParseDSN(o.dsn)
+			if err != nil {
+				return nil, fmt.Errorf("error code: 151: %w", err)
 			}
+		} else {
+			mysqlConfig, err = buildMySQLConfig(configureDB)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if tunnel != nil {
+			mysqlConfig.DialFunc = tunnel.DialContext
+		}
+		if o.password != nil {
+			passwordFn := o.password
+			err = mysqlConfig.Apply(gomysql.BeforeConnect(func(ctx context.Context, cfg *gomysql.Config) error {
+				pass, err := newConnPassword(ctx, passwordFn)
+				cfg.Passwd = pass
+				return err
+			}))
+			if err != nil {
+				return nil, fmt.Errorf("error code: 151: %w", err)
+			}
+		}
+
+		var connector sqldriver.Connector
+		connector, err = gomysql.NewConnector(mysqlConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error code: 151: %w", err)
+		}
+
+		err = connectWithRetry(ctx, driver, maxRetries, retryDelay, retryMaxDelay, withRedaction("", password, func() error {
+			sqlDB = sql.OpenDB(connector)
+			sqlDB.SetMaxIdleConns(maxIdleConns)       // max number of connections in the idle connection pool
+			sqlDB.SetMaxOpenConns(maxOpenConns)       // max number of open connections in the database
+			sqlDB.SetConnMaxLifetime(connMaxLifetime) // max amount of time a connection may be reused
+			sqlDB.SetConnMaxIdleTime(connMaxIdleTime) // max amount of time a connection may be idle
 
-			// perform comprehensive SSL/TLS certificate validation using
-			// certificate signed by a recognized CA or by a self-signed certificate
-			if sslmode == "verify-ca" || sslmode == "verify-full" {
-				dsn += "&tls=custom"
-				err = InitTLSMySQL()
+			if err = pingDB(ctx, sqlDB, connectTimeout); err != nil {
+				_ = sqlDB.Close()
+				return fmt.Errorf("error code: 156: %w", err)
+			}
+
+			db, err = gorm.Open(mysql.New(mysql.Config{
+				Conn: sqlDB,
+			}), &gorm.Config{
+				Logger:                                   gormLogger,
+				PrepareStmt:                              configureDB.Conn.PrepareStmt,
+				SkipDefaultTransaction:                   configureDB.Conn.SkipDefaultTransaction,
+				NamingStrategy:                           namingStrategy,
+				NowFunc:                                  o.nowFunc,
+				DisableForeignKeyConstraintWhenMigrating: disableFKConstraint,
+			})
+			if err != nil {
+				_ = sqlDB.Close()
+				return fmt.Errorf("error code: 152: %w", err)
+			}
+
+			return nil
+		}))
+		if err != nil {
+			return nil, err
+		}
+
+	case DriverPostgres, DriverCockroachDB:
+		dsn := o.dsn
+		if dsn == "" {
+			dsn, err = buildPostgresDSN(configureDB)
+			if err != nil {
+				return nil, err
+			}
+		}
+		timeZone := postgresTimeZone(configureDB, o.dsn)
+
+		err = connectWithRetry(ctx, driver, maxRetries, retryDelay, retryMaxDelay, withRedaction(dsn, password, func() error {
+			if tunnel != nil || o.password != nil || timeZone != "" {
+				var connConfig *pgx.ConnConfig
+				connConfig, err = pgx.ParseConfig(dsn)
+				if err != nil {
+					return fmt.Errorf("error code: 153: %w", err)
+				}
+				var pgxOpts []stdlib.OptionOpenDB
+				if tunnel != nil {
+					connConfig.DialFunc = tunnel.DialContext
+					connConfig.LookupFunc = lookupRemote
+				}
+				if o.password != nil {
+					passwordFn := o.password
+					pgxOpts = append(pgxOpts, stdlib.OptionBeforeConnect(func(ctx context.Context, cfg *pgx.ConnConfig) error {
+						pass, err := newConnPassword(ctx, passwordFn)
+						cfg.Password = pass
+						return err
+					}))
+				}
+				if timeZone != "" {
+					// the TimeZone startup parameter is not forwarded by
+					// every pooler, the server default would apply
+					setTimeZone := "SET TIME ZONE '" + strings.ReplaceAll(timeZone, "'", "''") + "'"
+					pgxOpts = append(pgxOpts, stdlib.OptionAfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+						_, err := conn.Exec(ctx, setTimeZone)
+						return err
+					}))
+				}
+				sqlDB = stdlib.OpenDB(*connConfig, pgxOpts...)
+			} else {
+				sqlDB, err = sql.Open("pgx", dsn)
 				if err != nil {
-					log.WithError(err).Panic("panic code: 150")
+					return fmt.Errorf("error code: 153: %w", err)
 				}
 			}
-		}
-		sqlDB, err = sql.Open(driver, dsn)
+			sqlDB.SetMaxIdleConns(maxIdleConns)       // max number of connections in the idle connection pool
+			sqlDB.SetMaxOpenConns(maxOpenConns)       // max number of open connections in the database
+			sqlDB.SetConnMaxLifetime(connMaxLifetime) // max amount of time a connection may be reused
+			sqlDB.SetConnMaxIdleTime(connMaxIdleTime) // max amount of time a connection may be idle
+
+			if err = pingDB(ctx, sqlDB, connectTimeout); err != nil {
+				_ = sqlDB.Close()
+				return fmt.Errorf("error code: 157: %w", err)
+			}
+
+			db, err = gorm.Open(postgres.New(postgres.Config{
+				Conn:                 sqlDB,
+				PreferSimpleProtocol: configureDB.Conn.PreferSimpleProtocol,
+			}), &gorm.Config{
+				Logger:                                   gormLogger,
+				PrepareStmt:                              configureDB.Conn.PrepareStmt,
+				SkipDefaultTransaction:                   configureDB.Conn.SkipDefaultTransaction,
+				NamingStrategy:                           namingStrategy,
+				NowFunc:                                  o.nowFunc,
+				DisableForeignKeyConstraintWhenMigrating: disableFKConstraint,
+			})
+			if err != nil {
+				_ = sqlDB.Close()
+				return fmt.Errorf("error code: 154: %w", err)
+			}
+
+			return nil
+		}))
 		if err != nil {
-			log.WithError(err).Panic("panic code: 151")
+			return nil, err
+		}
+		if driver == DriverCockroachDB {
+			// the transaction helpers retry the serialization failures
+			// of the connections marked by the plugin
+			if err = db.Use(cockroachPlugin{}); err != nil {
+				_ = sqlDB.Close()
+				return nil, fmt.Errorf("error code: 154: %w", err)
+			}
 		}
-		sqlDB.SetMaxIdleConns(maxIdleConns)       // max number of connections in the idle connection pool
-		sqlDB.SetMaxOpenConns(maxOpenConns)       // max number of open connections in the database
-		sqlDB.SetConnMaxLifetime(connMaxLifetime) // max amount of time a connection may be reused
 
-		db, err = gorm.Open(mysql.New(mysql.Config{
-			Conn: sqlDB,
-		}), &gorm.Config{
-			Logger: logger.Default.LogMode(logger.LogLevel(logLevel)),
+	case DriverSQLite:
+		database = buildSQLitePath(configureDB)
+		if o.dsn != "" {
+			database = o.dsn
+		}
+		// sqlite3 creates the file, but not the missing directories
+		if dir := sqliteDir(database); dir != "" {
+			if err := os.MkdirAll(dir, 0o700); err != nil {
+				return nil, fmt.Errorf("error code: 155: create the directory of the sqlite database: %w", err)
+			}
+		}
+		sqliteLogger := o.logger
+		if sqliteLogger == nil {
+			sqliteLogger = NewGormLogger(logger.Silent)
+		}
+		db, err = gorm.Open(sqlite.Open(database), &gorm.Config{
+			Logger:                                   sqliteLogger,
+			DisableForeignKeyConstraintWhenMigrating: disableFKConstraint,
+			PrepareStmt:                              configureDB.Conn.PrepareStmt,
+			SkipDefaultTransaction:                   configureDB.Conn.SkipDefaultTransaction,
+			NamingStrategy:                           namingStrategy,
+			NowFunc:                                  o.nowFunc,
 		})
 		if err != nil {
-			log.WithError(err).Panic("panic code: 152")
+			return nil, fmt.Errorf("error code: 155: %w", err)
 		}
-		// Only for debugging
-		if err == nil {
-			fmt.Println("DB connection successful!")
+		sqlDB, err = db.DB()
+		if err != nil {
+			return nil, fmt.Errorf("error code: 155: %w", err)
+		}
+		if err = pingDB(ctx, sqlDB, connectTimeout); err != nil {
+			_ = sqlDB.Close()
+			return nil, fmt.Errorf("error code: 155: %w", err)
+		}
+
+		// an in-memory database only lives as long as its connections
+		if isSQLiteMemory(database) {
+			sqlDB.SetConnMaxLifetime(0)
+			sqlDB.SetConnMaxIdleTime(0)
+			if strings.Contains(database, "cache=shared") {
+				// all connections share the database, keep one of them open
+				sqlDB.SetMaxIdleConns(max(maxIdleConns, 1))
+			} else {
+				// each connection would get its own empty database
+				sqlDB.SetMaxOpenConns(1)
+			}
 		}
 
-	case "postgres":
-		address := "host=" + host
-		if port != "" {
-			address += " port=" + port
+	case DriverSQLServer:
+		if sslmode == "verify-ca" || sslmode == "verify-full" {
+			if configureDB.Ssl.RootCA == "" && configureDB.Ssl.ServerCert == "" {
+				return nil, errors.New("error code: 159: missing server certificate")
+			}
 		}
-		dsn := address + " user=" + username + " dbname=" + database + " password=" + password + " TimeZone=" + timeZone
-		if sslmode == "" {
-			sslmode = "disable"
+		dsn := o.dsn
+		if dsn == "" {
+			dsn = sqlServerDSN(configureDB)
 		}
-		if sslmode != "disable" {
-			if configureDB.Ssl.RootCA != "" {
-				dsn += " sslrootcert=" + configureDB.Ssl.RootCA
-			} else if configureDB.Ssl.ServerCert != "" {
-				dsn += " sslrootcert=" + configureDB.Ssl.ServerCert
+
+		err = connectWithRetry(ctx, driver, maxRetries, retryDelay, retryMaxDelay, withRedaction(dsn, password, func() error {
+			sqlDB, err = sql.Open(driver, dsn)
+			if err != nil {
+				return fmt.Errorf("error code: 159.1: %w", err)
 			}
-			if configureDB.Ssl.ClientCert != "" {
-				dsn += " sslcert=" + configureDB.Ssl.ClientCert
+			sqlDB.SetMaxIdleConns(maxIdleConns)       // max number of connections in the idle connection pool
+			sqlDB.SetMaxOpenConns(maxOpenConns)       // max number of open connections in the database
+			sqlDB.SetConnMaxLifetime(connMaxLifetime) // max amount of time a connection may be reused
+			sqlDB.SetConnMaxIdleTime(connMaxIdleTime) // max amount of time a connection may be idle
+
+			if err = pingDB(ctx, sqlDB, connectTimeout); err != nil {
+				_ = sqlDB.Close()
+				return fmt.Errorf("error code: 159.3: %w", err)
 			}
-			if configureDB.Ssl.ClientKey != "" {
-				dsn += " sslkey=" + configureDB.Ssl.ClientKey
+
+			db, err = gorm.Open(sqlserver.New(sqlserver.Config{
+				Conn: sqlDB,
+			}), &gorm.Config{
+				Logger:                                   gormLogger,
+				PrepareStmt:                              configureDB.Conn.PrepareStmt,
+				SkipDefaultTransaction:                   configureDB.Conn.SkipDefaultTransaction,
+				NamingStrategy:                           namingStrategy,
+				NowFunc:                                  o.nowFunc,
+				DisableForeignKeyConstraintWhenMigrating: disableFKConstraint,
+			})
+			if err != nil {
+				_ = sqlDB.Close()
+				return fmt.Errorf("error code: 159.2: %w", err)
 			}
-		}
-		dsn += " sslmode=" + sslmode
 
-		sqlDB, err = sql.Open("pgx", dsn)
+			return nil
+		}))
 		if err != nil {
-			log.WithError(err).Panic("panic code: 153")
+			return nil, err
 		}
-		sqlDB.SetMaxIdleConns(maxIdleConns)       // max number of connections in the idle connection pool
-		sqlDB.SetMaxOpenConns(maxOpenConns)       // max number of open connections in the database
-		sqlDB.SetConnMaxLifetime(connMaxLifetime) // max amount of time a connection may be reused
 
-		db, err = gorm.Open(postgres.New(postgres.Config{
-			Conn: sqlDB,
-		}), &gorm.Config{
-			Logger: logger.Default.LogMode(logger.LogLevel(logLevel)),
-		})
+	// ClickHouse is an analytical database, GORM features depending on
+	// transactions, foreign keys, unique indexes or auto-increment
+	// primary keys are not supported. AutoMigrate creates new tables,
+	// but renaming columns and altering column types is not supported
+	// on older ClickHouse servers. Tables are created with the MergeTree
+	// engine unless the table options are set with
+	// db.Set("gorm:table_options", "ENGINE=...").
+	case DriverClickHouse:
+		compression := configureDB.Conn.Compression
+		if compression != "" && compression != "lz4" && compression != "zstd" {
+			return nil, errors.New("error code: 160: unsupported compression " + compression)
+		}
+		dsn := o.dsn
+		if dsn == "" {
+			dsn = clickHouseDSN(configureDB)
+		}
+
+		err = connectWithRetry(ctx, driver, maxRetries, retryDelay, retryMaxDelay, withRedaction(dsn, password, func() error {
+			sqlDB, err = sql.Open(driver, dsn)
+			if err != nil {
+				return fmt.Errorf("error code: 160.1: %w", err)
+			}
+			sqlDB.SetMaxIdleConns(maxIdleConns)       // max number of connections in the idle connection pool
+			sqlDB.SetMaxOpenConns(maxOpenConns)       // max number of open connections in the database
+			sqlDB.SetConnMaxLifetime(connMaxLifetime) // max amount of time a connection may be reused
+			sqlDB.SetConnMaxIdleTime(connMaxIdleTime) // max amount of time a connection may be idle
+
+			if err = pingDB(ctx, sqlDB, connectTimeout); err != nil {
+				_ = sqlDB.Close()
+				return fmt.Errorf("error code: 160.3: %w", err)
+			}
+
+			db, err = gorm.Open(clickhouse.New(clickhouse.Config{
+				Conn: sqlDB,
+			}), &gorm.Config{
+				Logger:                                   gormLogger,
+				PrepareStmt:                              configureDB.Conn.PrepareStmt,
+				SkipDefaultTransaction:                   configureDB.Conn.SkipDefaultTransaction,
+				NamingStrategy:                           namingStrategy,
+				NowFunc:                                  o.nowFunc,
+				DisableForeignKeyConstraintWhenMigrating: disableFKConstraint,
+			})
+			if err != nil {
+				_ = sqlDB.Close()
+				return fmt.Errorf("error code: 160.2: %w", err)
+			}
+
+			return nil
+		}))
 		if err != nil {
-			log.WithError(err).Panic("panic code: 154")
+			return nil, err
 		}
-		// Only for debugging
-		if err == nil {
-			fmt.Println("DB connection successful!")
+
+	default:
+		return nil, errors.New("the driver " + driver + " is not implemented yet")
+	}
+
+	if configureDB.Log.EnableTracing {
+		if err = registerTracing(db); err != nil {
+			if sqlDB, errDB := db.DB(); errDB == nil {
+				_ = sqlDB.Close()
+			}
+			return nil, fmt.Errorf("error code: 163: %w", err)
 		}
+	}
 
-	case "sqlite3":
-		db, err = gorm.Open(sqlite.Open(database), &gorm.Config{
-			Logger:                                   logger.Default.LogMode(logger.Silent),
-			DisableForeignKeyConstraintWhenMigrating: true,
-		})
+	// the replicas connect with the credentials of the primary, the
+	// DSN of WithDSN or DATABASE_URL only applies to the primary
+	var replicaOpts []DBOption
+	if o.credentials != nil {
+		replicaOpts = append(replicaOpts, WithCredentialProvider(o.credentials))
+	}
+	if o.password != nil {
+		replicaOpts = append(replicaOpts, WithPasswordProvider(o.password))
+	}
+	if err = registerReplicas(ctx, db, configureDB, replicaOpts...); err != nil {
+		if sqlDB, errDB := db.DB(); errDB == nil {
+			_ = sqlDB.Close()
+		}
+		return nil, err
+	}
+
+	if err = usePlugins(db, append(registeredPlugins(), o.plugins...)); err != nil {
+		_ = closePool(db)
+		return nil, fmt.Errorf("error code: 167: %w", err)
+	}
+
+	logFields := log.Fields{"driver": driver, "database": database, "source": "config"}
+	if driver != DriverSQLite {
+		logFields["host"] = host
+	}
+	if socket != "" && (driver == DriverMySQL || isPostgresDriver(driver)) {
+		logFields["host"] = socket
+	}
+	if connURL != "" {
+		// already validated by validateURLScheme, the password is not logged
+		if u, errURL := url.Parse(connURL); errURL == nil {
+			logFields["host"] = u.Hostname()
+			logFields["database"] = strings.TrimPrefix(u.Path, "/")
+		}
+	}
+	if o.dsn != "" && driver != DriverSQLite {
+		// the host and the database are set in the DSN
+		delete(logFields, "host")
+		delete(logFields, "database")
+		logFields["dsn"] = maskDSN(o.dsn)
+	}
+	if o.dsn != "" {
+		logFields["source"] = o.dsnSource
+	}
+	if tunnel != nil {
+		logFields["ssh_tunnel"] = tunnel.addr
+	}
+	if configureDB.Env.Schema != "" && o.dsn == "" {
+		logFields["schema"] = configureDB.Env.Schema
+	}
+	if n := len(configureDB.Replica.Hosts) + len(configureDB.Replica.URLs); n > 0 {
+		logFields["replicas"] = n
+	}
+	log.WithFields(logFields).Info("database connection successful")
+
+	return db, nil
+}
+
+// withForceUTC - configureDB with TimeZone and Loc set to UTC when
+// DBFORCE_UTC is enabled
+func withForceUTC(configureDB config.RDBMS) config.RDBMS {
+	if configureDB.Env.ForceUTC {
+		configureDB.Env.TimeZone = "UTC"
+		configureDB.Env.Loc = "UTC"
+	}
+
+	return configureDB
+}
+
+// postgresTimeZone - time zone set on the new postgres connections,
+// DBTIMEZONE unless the connection settings are replaced by DBURL or
+// dsn, UTC with DBFORCE_UTC
+func postgresTimeZone(configureDB config.RDBMS, dsn string) string {
+	if dsn != "" || (configureDB.Access.URL != "" && !configureDB.Env.ForceUTC) {
+		return ""
+	}
+
+	return configureDB.Env.TimeZone
+}
+
+// validTimeZone - whether tz can be sent in SET TIME ZONE, the server
+// validates the zone itself, it accepts names unknown to Go and the
+// application host may not have a time zone database
+func validTimeZone(tz string) bool {
+	for _, c := range tz {
+		if c == '\'' || c == '"' || unicode.IsControl(c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSQLiteMemory - whether the sqlite database is kept in memory,
+// e.g. ":memory:", "file::memory:?cache=shared" or
+// "file:test?mode=memory&cache=shared"
+func isSQLiteMemory(database string) bool {
+	return database == ":memory:" ||
+		strings.HasPrefix(database, "file::memory:") ||
+		(strings.HasPrefix(database, "file:") && strings.Contains(database, "mode=memory"))
+}
+
+// sqliteDir - parent directory of the sqlite database file, "" for an
+// in-memory database or a file in the working directory
+//
+// Both paths and "file:" URIs are supported.
+func sqliteDir(database string) string {
+	if database == "" || isSQLiteMemory(database) {
+		return ""
+	}
+
+	path := database
+	if strings.HasPrefix(path, "file:") {
+		path = strings.TrimPrefix(path, "file:")
+		if i := strings.IndexByte(path, '?'); i >= 0 {
+			path = path[:i]
+		}
+		// file:///data/app.db or file://localhost/data/app.db
+		if strings.HasPrefix(path, "//") {
+			path = strings.TrimPrefix(path[2:], "localhost")
+		}
+	}
+	if path == "" {
+		return ""
+	}
+
+	dir := filepath.Dir(path)
+	if dir == "." {
+		return ""
+	}
+
+	return dir
+}
+
+// validateSSLFiles - verify that the configured certificate and key
+// files exist and are readable, and that the client certificate and
+// key are set together
+func validateSSLFiles(configureDB config.RDBMS) error {
+	clientCert := configureDB.Ssl.ClientCert
+	clientKey := configureDB.Ssl.ClientKey
+	if (clientCert == "") != (clientKey == "") {
+		return errors.New("DBSSL_CLIENT_CERT and DBSSL_CLIENT_KEY must be set together")
+	}
+
+	files := []struct {
+		name string
+		path string
+	}{
+		{"DBSSL_ROOT_CA", configureDB.Ssl.RootCA},
+		{"DBSSL_SERVER_CERT", configureDB.Ssl.ServerCert},
+		{"DBSSL_CLIENT_CERT", clientCert},
+		{"DBSSL_CLIENT_KEY", clientKey},
+	}
+	for _, file := range files {
+		if file.path == "" {
+			continue
+		}
+		f, err := os.Open(file.path)
 		if err != nil {
-			log.WithError(err).Panic("panic code: 155")
+			return fmt.Errorf("%s: %w", file.name, err)
 		}
-		// Only for debugging
-		if err == nil {
-			fmt.Println("DB connection successful!")
+		info, err := f.Stat()
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", file.name, err)
 		}
+		if info.IsDir() {
+			return errors.New(file.name + ": " + file.path + " is a directory")
+		}
+	}
+
+	return nil
+}
+
+// supportedDrivers - drivers implemented by openDB
+var supportedDrivers = []string{DriverMySQL, DriverPostgres, DriverSQLite, DriverSQLServer, DriverClickHouse, DriverCockroachDB}
+
+// validateRDBMSConfig - verify that the driver is supported and that
+// the fields required by the driver are set
+//
+// The missing fields are reported with the names of their environment
+// variables.
+func validateRDBMSConfig(configureDB config.RDBMS) error {
+	driver := configureDB.Env.Driver
+	if driver == "" {
+		return errors.New("DBDRIVER is not set, supported drivers: " + strings.Join(supportedDrivers, ", "))
+	}
+	if schema := configureDB.Env.Schema; schema != "" {
+		if !isPostgresDriver(driver) {
+			return errors.New("DBSCHEMA is only supported by postgres and cockroachdb")
+		}
+		if !validSchemaName(schema) {
+			return errors.New("DBSCHEMA " + strconv.Quote(schema) + " is not a valid schema name")
+		}
+	}
+	if tz := configureDB.Env.TimeZone; tz != "" && isPostgresDriver(driver) && !validTimeZone(tz) {
+		return errors.New("DBTIMEZONE " + strconv.Quote(tz) + " is not a valid time zone")
+	}
 
+	var required []string
+	switch driver {
+	case DriverSQLite:
+		required = []string{"DBNAME"}
+	case DriverPostgres, DriverCockroachDB:
+		if configureDB.Access.URL != "" {
+			return nil
+		}
+		required = []string{"DBHOST", "DBUSER", "DBNAME"}
+		if configureDB.Env.Socket != "" {
+			required = []string{"DBUSER", "DBNAME"}
+		}
+	case DriverMySQL:
+		required = []string{"DBHOST", "DBUSER", "DBNAME"}
+		if configureDB.Env.Socket != "" {
+			required = []string{"DBUSER", "DBNAME"}
+		}
+	case DriverSQLServer:
+		required = []string{"DBHOST", "DBUSER"}
+	case DriverClickHouse:
+		required = []string{"DBHOST"}
 	default:
-		log.Fatal("The driver " + driver + " is not implemented yet")
+		return errors.New("the driver " + driver + " is not supported, supported drivers: " + strings.Join(supportedDrivers, ", "))
 	}
 
-	dbClient = db
+	values := map[string]string{
+		"DBHOST": configureDB.Env.Host,
+		"DBUSER": configureDB.Access.User,
+		"DBNAME": configureDB.Access.DbName,
+	}
 
-	return dbClient
+	var missing []string
+	for _, field := range required {
+		if strings.TrimSpace(values[field]) == "" {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New("missing required config for the driver " + driver + ": " + strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// validSchemaName - whether schema is an unquoted postgres identifier,
+// the name is sent in the DSN and must not change the search_path
+// beyond the one schema
+func validSchemaName(schema string) bool {
+	// NAMEDATALEN - 1
+	if len(schema) > 63 {
+		return false
+	}
+	for i, c := range schema {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i > 0 && (c == '$' || c >= '0' && c <= '9'):
+		default:
+			return false
+		}
+	}
+
+	return schema != ""
+}
+
+// validateSocket - check that the unix socket exists before connecting
+//
+// mysql expects the path of the socket file, postgres expects the
+// directory containing the socket file.
+func validateSocket(driver, socket string) error {
+	info, err := os.Stat(socket)
+	if err != nil {
+		return fmt.Errorf("DBSOCKET: %w", err)
+	}
+
+	if isPostgresDriver(driver) && !info.IsDir() {
+		return errors.New("DBSOCKET: " + socket + " is not a directory")
+	}
+	if driver == DriverMySQL && info.Mode()&os.ModeSocket == 0 {
+		return errors.New("DBSOCKET: " + socket + " is not a unix socket")
+	}
+
+	return nil
+}
+
+// pingDB - verify that the database is reachable and the credentials
+// are valid, the ping is aborted when ctx is done or after timeout
+// (if > 0)
+func pingDB(ctx context.Context, sqlDB *sql.DB, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("cannot reach database: %w", err)
+	}
+
+	return nil
+}
+
+// buildMySQLConfig - build the mysql driver config of configureDB
+//
+// An empty charset or loc defaults to utf8mb4 and Local.
+//
+// The credentials are set in the typed fields of the config, so
+// special characters in them do not break the DSN. With sslmode
+// verify-ca or verify-full, the certificates are loaded into the TLS
+// field of the config.
+func buildMySQLConfig(configureDB config.RDBMS) (*gomysql.Config, error) {
+	network := "tcp"
+	address := configureDB.Env.Host
+	if configureDB.Env.Port != "" {
+		address += ":" + configureDB.Env.Port
+	}
+	if configureDB.Env.Socket != "" {
+		// DBHOST and DBPORT are ignored
+		network = "unix"
+		address = configureDB.Env.Socket
+	}
+
+	charset := configureDB.Env.Charset
+	if charset == "" {
+		charset = config.DefaultMySQLCharset
+	}
+	loc := configureDB.Env.Loc
+	if loc == "" {
+		loc = config.DefaultMySQLLoc
+	}
+	if _, err := time.LoadLocation(loc); err != nil {
+		return nil, fmt.Errorf("error code: 151: DBLOC: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set("charset", charset)
+	query.Set("parseTime", strconv.FormatBool(configureDB.Env.ParseTime))
+	query.Set("loc", loc)
+	mysqlConfig, err := gomysql.ParseDSN("/?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("error code: 151: %w", err)
+	}
+	mysqlConfig.Collation = configureDB.Env.Collation
+	mysqlConfig.User = configureDB.Access.User
+	mysqlConfig.Passwd = configureDB.Access.Pass
+	mysqlConfig.Net = network
+	mysqlConfig.Addr = address
+	mysqlConfig.DBName = configureDB.Access.DbName
+	if configureDB.Conn.QueryTimeout > 0 || configureDB.Env.ForceUTC {
+		mysqlConfig.Params = map[string]string{}
+	}
+	if configureDB.Conn.QueryTimeout > 0 {
+		// only applies to read-only SELECT statements
+		mysqlConfig.Params["max_execution_time"] = queryTimeoutMillis(configureDB.Conn.QueryTimeout)
+	}
+	if configureDB.Env.ForceUTC {
+		// TIMESTAMP columns are converted with the time zone of the
+		// session
+		mysqlConfig.Params["time_zone"] = "'+00:00'"
+	}
+
+	sslmode := configureDB.Ssl.Sslmode
+	if sslmode == "" {
+		sslmode = "disable"
+	}
+	if sslmode != "disable" {
+		// use host machine's root CAs to verify
+		if sslmode == "require" {
+			mysqlConfig.TLSConfig = "true"
+		}
+
+		// perform comprehensive SSL/TLS certificate validation using
+		// certificate signed by a recognized CA or by a self-signed certificate
+		if sslmode == "verify-ca" || sslmode == "verify-full" {
+			mysqlConfig.TLS, err = tlsConfigMySQL(configureDB)
+			if err != nil {
+				return nil, fmt.Errorf("error code: 150: %w", err)
+			}
+		}
+	}
+
+	return mysqlConfig, nil
+}
+
+// buildMySQLDSN - build the mysql DSN of configureDB
+//
+// The loaded certificates of sslmode verify-ca and verify-full can not
+// be expressed in a DSN, openDB uses buildMySQLConfig instead.
+func buildMySQLDSN(configureDB config.RDBMS) (string, error) {
+	mysqlConfig, err := buildMySQLConfig(configureDB)
+	if err != nil {
+		return "", err
+	}
+
+	return mysqlConfig.FormatDSN(), nil
+}
+
+// buildPostgresDSN - build the keyword/value postgres DSN of
+// configureDB, or the connection URL when DBURL is set
+func buildPostgresDSN(configureDB config.RDBMS) (string, error) {
+	connURL := configureDB.Access.URL
+	if connURL != "" {
+		if !configureDB.Conn.PreferSimpleProtocol && configureDB.Conn.QueryTimeout <= 0 &&
+			configureDB.Env.AppName == "" && configureDB.Env.Schema == "" && !configureDB.Env.ForceUTC {
+			return connURL, nil
+		}
+
+		// do not wrap the parser error, it contains the full URL with the password
+		u, err := url.Parse(connURL)
+		if err != nil {
+			return "", errors.New("error code: 158: invalid connection URL")
+		}
+		query := u.Query()
+		if configureDB.Conn.PreferSimpleProtocol {
+			query.Set("default_query_exec_mode", "simple_protocol")
+		}
+		if configureDB.Conn.QueryTimeout > 0 {
+			query.Set("statement_timeout", queryTimeoutMillis(configureDB.Conn.QueryTimeout))
+		}
+		if configureDB.Env.AppName != "" && !query.Has("application_name") {
+			// the name set in the URL takes precedence
+			query.Set("application_name", configureDB.Env.AppName)
+		}
+		if configureDB.Env.ForceUTC && !query.Has("TimeZone") {
+			query.Set("TimeZone", "UTC")
+		}
+		if configureDB.Env.Schema != "" && !query.Has("search_path") {
+			// the search_path set in the URL takes precedence
+			query.Set("search_path", configureDB.Env.Schema)
+		}
+		u.RawQuery = query.Encode()
+
+		return u.String(), nil
+	}
+
+	address := "host=" + quotePostgresValue(configureDB.Env.Host)
+	if configureDB.Env.Socket != "" {
+		// DBHOST is ignored, DBPORT selects the socket file
+		// <socket>/.s.PGSQL.<port> of the directory
+		address = "host=" + quotePostgresValue(configureDB.Env.Socket)
+	}
+	if configureDB.Env.Port != "" {
+		address += " port=" + quotePostgresValue(configureDB.Env.Port)
+	}
+	dsn := address +
+		" user=" + quotePostgresValue(configureDB.Access.User) +
+		" dbname=" + quotePostgresValue(configureDB.Access.DbName) +
+		" password=" + quotePostgresValue(configureDB.Access.Pass)
+	if configureDB.Env.TimeZone != "" {
+		// the server default is used otherwise
+		dsn += " TimeZone=" + quotePostgresValue(configureDB.Env.TimeZone)
+	}
+
+	sslmode := configureDB.Ssl.Sslmode
+	if sslmode == "" {
+		sslmode = "disable"
+	}
+	if sslmode != "disable" {
+		if err := validateSSLFiles(configureDB); err != nil {
+			return "", fmt.Errorf("error code: 165: %w", err)
+		}
+		if configureDB.Ssl.RootCA != "" {
+			dsn += " sslrootcert=" + quotePostgresValue(configureDB.Ssl.RootCA)
+		} else if configureDB.Ssl.ServerCert != "" {
+			dsn += " sslrootcert=" + quotePostgresValue(configureDB.Ssl.ServerCert)
+		}
+		if configureDB.Ssl.ClientCert != "" {
+			dsn += " sslcert=" + quotePostgresValue(configureDB.Ssl.ClientCert)
+		}
+		if configureDB.Ssl.ClientKey != "" {
+			dsn += " sslkey=" + quotePostgresValue(configureDB.Ssl.ClientKey)
+		}
+	}
+	dsn += " sslmode=" + quotePostgresValue(sslmode)
+	if configureDB.Conn.PreferSimpleProtocol {
+		dsn += " default_query_exec_mode=simple_protocol"
+	}
+	if configureDB.Conn.QueryTimeout > 0 {
+		dsn += " statement_timeout=" + queryTimeoutMillis(configureDB.Conn.QueryTimeout)
+	}
+	if configureDB.Env.AppName != "" {
+		dsn += " application_name=" + quotePostgresValue(configureDB.Env.AppName)
+	}
+	if configureDB.Env.Schema != "" {
+		dsn += " search_path=" + quotePostgresValue(configureDB.Env.Schema)
+	}
+
+	return dsn, nil
+}
+
+// buildSQLitePath - path or URI of the sqlite database
+func buildSQLitePath(configureDB config.RDBMS) string {
+	return strings.TrimSpace(configureDB.Access.DbName)
+}
+
+// sqlServerDSN - build the URL of a SQL Server database, special
+// characters in the credentials are escaped
+//
+// sslmode:
+//
+// - disable (default): only the login packet is encrypted
+//
+// - require: encrypt all data, the server certificate is verified with
+// the host machine's root CAs
+//
+// - verify-ca, verify-full: encrypt all data, the server certificate is
+// verified with the configured root CA or server certificate
+func sqlServerDSN(configureDB config.RDBMS) string {
+	address := configureDB.Env.Host
+	if configureDB.Env.Port != "" {
+		address += ":" + configureDB.Env.Port
+	}
+
+	query := url.Values{}
+	query.Set("database", configureDB.Access.DbName)
+
+	sslmode := configureDB.Ssl.Sslmode
+	if sslmode == "require" || sslmode == "verify-ca" || sslmode == "verify-full" {
+		query.Set("encrypt", "true")
+	}
+	if sslmode == "verify-ca" || sslmode == "verify-full" {
+		certificate := configureDB.Ssl.RootCA
+		if certificate == "" {
+			certificate = configureDB.Ssl.ServerCert
+		}
+		query.Set("certificate", certificate)
+	}
+
+	dsn := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(configureDB.Access.User, configureDB.Access.Pass),
+		Host:     address,
+		RawQuery: query.Encode(),
+	}
+
+	return dsn.String()
+}
+
+// clickHouseDSN - build the URL of a ClickHouse database for the
+// native protocol, special characters in the credentials are escaped
+//
+// sslmode require, verify-ca and verify-full enable TLS, the server
+// certificate is verified with the host machine's root CAs
+func clickHouseDSN(configureDB config.RDBMS) string {
+	address := configureDB.Env.Host
+	if configureDB.Env.Port != "" {
+		address += ":" + configureDB.Env.Port
+	}
+
+	query := url.Values{}
+	if configureDB.Conn.Compression != "" {
+		query.Set("compress", configureDB.Conn.Compression)
+	}
+
+	sslmode := configureDB.Ssl.Sslmode
+	if sslmode == "require" || sslmode == "verify-ca" || sslmode == "verify-full" {
+		query.Set("secure", "true")
+	}
+
+	dsn := url.URL{
+		Scheme:   "clickhouse",
+		User:     url.UserPassword(configureDB.Access.User, configureDB.Access.Pass),
+		Host:     address,
+		Path:     "/" + configureDB.Access.DbName,
+		RawQuery: query.Encode(),
+	}
+
+	return dsn.String()
+}
+
+// quotePostgresValue - quote a value of a keyword/value postgres DSN,
+// so that spaces, quotes and backslashes in the value are preserved
+func quotePostgresValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+
+	return "'" + value + "'"
+}
+
+// validateURLScheme - verify that the scheme of a connection URL
+// matches the configured driver
+func validateURLScheme(driver, connURL string) error {
+	if !isPostgresDriver(driver) {
+		return errors.New("connection URL is not supported for the driver " + driver)
+	}
+
+	// do not wrap the parser error, it contains the full URL with the password
+	u, err := url.Parse(connURL)
+	if err != nil {
+		return errors.New("invalid connection URL")
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return errors.New("scheme '" + u.Scheme + "' of the connection URL does not match the driver " + driver)
+	}
+
+	return nil
 }
 
 // GetDB - get a connection
+//
+// See LazyInit to initialize the connection on the first call.
 func GetDB() *gorm.DB {
+	db := currentDB()
+	if db != nil || !LazyInit {
+		return db
+	}
+
+	lazyDBMu.Lock()
+	defer lazyDBMu.Unlock()
+
+	if db = currentDB(); db != nil {
+		return db
+	}
+	if config.GetConfig() == nil || !config.IsRDBMS() {
+		return nil
+	}
+	db, err := InitDB()
+	if err != nil {
+		log.WithError(err).Error("database: lazy initialization failed")
+		return nil
+	}
+
+	return db
+}
+
+// currentDB - the default connection, nil if not initialized
+func currentDB() *gorm.DB {
+	dbRegistryMu.RLock()
+	defer dbRegistryMu.RUnlock()
+
 	return dbClient
 }
 
+// GetSQLDB - get the underlying connection pool of the relational
+// database, e.g. to read the pool statistics with Stats()
+//
+// The error is a *NotActivatedError when ACTIVATE_RDBMS is not set
+// to yes.
+func GetSQLDB() (*sql.DB, error) {
+	db := GetDB()
+	if db == nil {
+		if rdbmsDeactivated(config.GetConfig()) {
+			return nil, &NotActivatedError{Datastore: "rdbms"}
+		}
+		return nil, ErrDBNotInitialized
+	}
+
+	return db.DB()
+}
+
+// CloseDB - close the connection pool of the relational database
+//
+// It is safe to call CloseDB multiple times. After closing,
+// GetDB returns nil until InitDB is called again.
+func CloseDB() error {
+	stopDBMetrics()
+
+	dbRegistryMu.Lock()
+	client := dbClient
+	dbClient = nil
+	delete(dbRegistry, DefaultDBName)
+	dbRegistryMu.Unlock()
+
+	if client == nil {
+		return nil
+	}
+
+	if err := closePool(client); err != nil {
+		return fmt.Errorf("%s: %w", client.Dialector.Name(), err)
+	}
+
+	return nil
+}
+
+// ReconnectDB - replace the connection pool of the relational database
+// with a new one
+//
+// The new pool is opened first and swapped in under the lock, so that
+// GetDB never returns nil or a half-initialized pool. The old pool is
+// closed afterwards, queries still running on it may fail. When the
+// new pool can not be opened, the old pool is kept.
+func ReconnectDB() error {
+	return reconnectDB(config.GetConfig().Database.RDBMS)
+}
+
+// reconnectDB - open a new pool for configureDB and swap it in
+func reconnectDB(configureDB config.RDBMS) error {
+	log.WithField("driver", configureDB.Env.Driver).Info("reconnecting to the database")
+
+	db, err := openDB(context.Background(), configureDB, defaultConnOptions()...)
+	if err != nil {
+		log.WithError(err).Error("database reconnect failed")
+		return err
+	}
+	warmUpDB(context.Background(), db, configureDB.Conn.WarmUp, maxIdleConnsOf(configureDB))
+	acquireTimeout.Store(int64(configureDB.Conn.AcquireTimeout))
+
+	dbRegistryMu.Lock()
+	old := dbClient
+	dbClient = db
+	dbRegistry[DefaultDBName] = db
+	dbRegistryMu.Unlock()
+
+	if old != nil {
+		if errClose := closePool(old); errClose != nil {
+			log.WithError(errClose).Warn("failed to close the previous connection pool")
+		}
+	}
+
+	log.Info("database reconnect successful")
+
+	return nil
+}
+
 // InitRedis - function to initialize redis client
+//
+// Depending on the config, the client connects to a single node,
+// to the current master through Redis Sentinel, or to a Redis Cluster.
+//
+// In cluster mode, radix routes each command to the node owning the
+// slot of its first key and follows MOVED/ASK redirects. Commands
+// touching several keys (MGET, MSET, MULTI/EXEC, EVAL) only work when
+// all keys hash to the same slot (use hash tags, e.g. {user:1}:token),
+// SELECT is not supported, and commands without a key are sent to a
+// random primary.
+//
+// When ACTIVATE_REDIS is not set to yes, InitRedis does nothing and
+// returns a nil client without error.
+//
+// InitRedis panics when the initialization fails, as it always did, use
+// InitRedisContext to get the error instead.
 func InitRedis() (*radix.Client, error) {
-	configureRedis := config.GetConfig().Database.REDIS
+	client, err := InitRedisContext(context.Background())
+	if err != nil {
+		log.WithError(err).Panic("panic code: 161")
+	}
+
+	return client, err
+}
+
+// InitRedisContext - initialize redis client, the connection attempt
+// is aborted when ctx is done or after CONNTTL seconds
+//
+// Unlike InitRedis, the error is returned when the connection fails.
+func InitRedisContext(ctx context.Context) (*radix.Client, error) {
+	if redisDeactivated(config.GetConfig()) {
+		log.Info("redis: not activated, skipping initialization")
+		return nil, nil
+	}
+
+	return initRedis(ctx, config.GetConfig().Database.REDIS)
+}
 
+// initRedis - initialize the redis client described by configureRedis
+func initRedis(ctx context.Context, configureRedis config.REDIS) (*radix.Client, error) {
 	RedisConnTTL = configureRedis.Conn.ConnTTL
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(RedisConnTTL)*time.Second)
+
+	dialer, err := validatedRedisDialer(configureRedis)
+	if err != nil {
+		return nil, err
+	}
+
+	rClient, err := openRedis(ctx, configureRedis, dialer)
+	if err != nil {
+		log.WithError(err).Error("error code: 161: redis connection failed")
+		return nil, err
+	}
+	redisPoolSize.Store(int64(configureRedis.Conn.PoolSize))
+
+	client := &rClient
+	stopRedisHealthCheck()
+	setRedis(client)
+	startRedisHealthCheck(configureRedis)
+
+	return client, nil
+}
+
+// openRedis - open a new redis client described by configureRedis,
+// the connection attempt is aborted when ctx is done or after CONNTTL
+// seconds
+func openRedis(ctx context.Context, configureRedis config.REDIS, dialer radix.Dialer) (rClient radix.Client, err error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(configureRedis.Conn.ConnTTL)*time.Second)
 	defer cancel()
 
-	rClient, err := (radix.PoolConfig{
-		Size: configureRedis.Conn.PoolSize,
-	}).New(ctx, "tcp", fmt.Sprintf("%v:%v",
-		configureRedis.Env.Host,
-		configureRedis.Env.Port))
+	poolConfig := radix.PoolConfig{
+		Dialer: dialer,
+		Size:   configureRedis.Conn.PoolSize,
+		Trace:  redisPoolTrace(),
+	}
+
+	sentinelMaster := configureRedis.Sentinel.MasterName
+	sentinelAddrs := configureRedis.Sentinel.Addrs
+	clusterAddrs := configureRedis.Cluster.Addrs
+
+	isSentinel := sentinelMaster != "" && len(sentinelAddrs) > 0
+	isCluster := len(clusterAddrs) > 0
+
+	if isCluster {
+		// the topology of the cluster is discovered from the seed nodes
+		var cluster *radix.Cluster
+		cluster, err = (radix.ClusterConfig{
+			PoolConfig: poolConfig,
+		}).New(ctx, clusterAddrs)
+		if err == nil {
+			rClient = multiClient{MultiClient: cluster}
+		}
+	} else if isSentinel {
+		// connect to the current master through the sentinels,
+		// failovers are handled by the sentinel client
+		var sentinel *radix.Sentinel
+		sentinel, err = (radix.SentinelConfig{
+			PoolConfig:     poolConfig,
+			SentinelDialer: dialer,
+		}).New(ctx, sentinelMaster, sentinelAddrs)
+		if err == nil {
+			rClient = multiClient{MultiClient: sentinel}
+		}
+	} else {
+		rClient, err = poolConfig.New(ctx, "tcp", fmt.Sprintf("%v:%v",
+			configureRedis.Env.Host,
+			configureRedis.Env.Port))
+	}
 	if err != nil {
-		log.WithError(err).Panic("panic code: 161")
-		return &rClient, err
+		return nil, err
+	}
+	logFields := log.Fields{"mode": "standalone"}
+	if !isCluster && !isSentinel {
+		logFields["addr"] = rClient.Addr().String()
+	}
+	if isCluster {
+		logFields["mode"] = "cluster"
+		logFields["addr"] = strings.Join(clusterAddrs, ",")
+	} else if isSentinel {
+		logFields["mode"] = "sentinel"
+		logFields["master"] = sentinelMaster
+	}
+	log.WithFields(logFields).Info("redis connection successful")
+
+	return rClient, nil
+}
+
+// validatedRedisDialer - dialer of configureRedis, after checking that
+// at most one of sentinel and cluster is enabled
+func validatedRedisDialer(configureRedis config.REDIS) (radix.Dialer, error) {
+	dialer, err := redisDialer(configureRedis)
+	if err != nil {
+		return dialer, err
+	}
+	if configureRedis.Sentinel.MasterName != "" && len(configureRedis.Sentinel.Addrs) > 0 &&
+		len(configureRedis.Cluster.Addrs) > 0 {
+		return dialer, errors.New("redis: sentinel and cluster can not be enabled at the same time")
+	}
+
+	return dialer, nil
+}
+
+// redisDialer - build the dialer used for all connections to redis
+func redisDialer(configureRedis config.REDIS) (dialer radix.Dialer, err error) {
+	// AUTH <pass> when only the password is set,
+	// AUTH <user> <pass> for ACL users
+	dialer.AuthUser = configureRedis.Access.User
+	dialer.AuthPass = configureRedis.Access.Pass
+
+	redisDB := configureRedis.Conn.DB
+	if redisDB < 0 || redisDB > MaxRedisDB {
+		err = fmt.Errorf("redis: database index %d is out of range [0, %d]", redisDB, MaxRedisDB)
+		return
+	}
+	if redisDB != 0 {
+		if len(configureRedis.Cluster.Addrs) > 0 {
+			err = errors.New("redis: SELECT is not supported in cluster mode")
+			return
+		}
+		dialer.SelectDB = strconv.Itoa(redisDB)
+	}
+
+	if configureRedis.Conn.UseTLS {
+		tlsConfig, errThis := tlsConfigRedis(configureRedis)
+		if errThis != nil {
+			err = fmt.Errorf("redis: %w", errThis)
+			return
+		}
+		dialer.NetDialer = &tls.Dialer{
+			NetDialer: &net.Dialer{Timeout: configureRedis.Conn.DialTimeout},
+			Config:    tlsConfig,
+		}
+	} else if configureRedis.Conn.DialTimeout > 0 {
+		dialer.NetDialer = &net.Dialer{Timeout: configureRedis.Conn.DialTimeout}
+	}
+
+	if writeTimeout := configureRedis.Conn.WriteTimeout; writeTimeout > 0 {
+		netDialer := dialer.NetDialer
+		if netDialer == nil {
+			netDialer = &net.Dialer{}
+		}
+		dialer.NetDialer = redisNetDialer{dialer: netDialer, writeTimeout: writeTimeout}
 	}
-	// Only for debugging
-	fmt.Println("REDIS pool connection successful!")
 
-	redisClient = &rClient
+	readTimeout := configureRedis.Conn.ReadTimeout
+	maxLifetime := configureRedis.Conn.MaxLifetime
+	if readTimeout > 0 || maxLifetime > 0 {
+		inner := dialer
+		dialer.CustomConn = func(ctx context.Context, network, addr string) (radix.Conn, error) {
+			conn, err := inner.Dial(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return newRedisConn(conn, readTimeout, maxLifetime), nil
+		}
+	}
 
-	return redisClient, nil
+	return
 }
 
 // GetRedis - get a connection
+//
+// It returns nil when redis is not initialized, e.g. because
+// ACTIVATE_REDIS is not set to yes.
+//
+// See LazyInit to initialize the client on the first call.
 func GetRedis() *radix.Client {
+	client := currentRedis()
+	if client != nil || !LazyInit {
+		return client
+	}
+
+	lazyRedisMu.Lock()
+	defer lazyRedisMu.Unlock()
+
+	if client = currentRedis(); client != nil {
+		return client
+	}
+	if config.GetConfig() == nil || !config.IsRedis() {
+		return nil
+	}
+
+	client, err := InitRedisContext(context.Background())
+	if err != nil {
+		log.WithError(err).Error("redis: lazy initialization failed")
+		return nil
+	}
+
+	return client
+}
+
+// currentRedis - the redis client, nil if not initialized
+func currentRedis() *radix.Client {
+	redisMu.RLock()
+	defer redisMu.RUnlock()
+
 	return redisClient
 }
 
+// setRedis - replace the redis client
+func setRedis(client *radix.Client) {
+	redisMu.Lock()
+	defer redisMu.Unlock()
+
+	redisClient = client
+}
+
+// GetRedisAddr - get the address of the redis instance the client
+// is connected to
+//
+// When Redis Sentinel is used, it is the address of the current master.
+func GetRedisAddr() string {
+	client := currentRedis()
+	if client == nil {
+		return ""
+	}
+
+	addr := (*client).Addr()
+	if addr == nil {
+		return ""
+	}
+
+	return addr.String()
+}
+
+// CloseRedis - close the redis connection pool
+//
+// It is safe to call CloseRedis multiple times.
+func CloseRedis() error {
+	stopRedisHealthCheck()
+
+	redisMu.Lock()
+	current := redisClient
+	redisClient = nil
+	redisMu.Unlock()
+
+	if current == nil {
+		return nil
+	}
+	client := *current
+
+	if err := client.Close(); err != nil {
+		return fmt.Errorf("redis: failed to close connection pool: %w", err)
+	}
+
+	return nil
+}
+
 // InitMongo - function to initialize mongo client
+//
+// When ACTIVATE_MONGO is not set to yes, InitMongo does nothing and
+// returns a nil client without error.
 func InitMongo() (*qmgo.Client, error) {
-	configureMongo := config.GetConfig().Database.MongoDB
+	if mongoDeactivated(config.GetConfig()) {
+		log.Info("mongo: not activated, skipping initialization")
+		return nil, nil
+	}
 
+	return initMongo(config.GetConfig().Database.MongoDB)
+}
+
+// initMongo - initialize the mongo client described by configureMongo
+func initMongo(configureMongo config.MongoDB) (*qmgo.Client, error) {
 	// Connect to the database or cluster
 	uri := configureMongo.Env.URI
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(configureMongo.Env.ConnTTL)*time.Second)
-	defer cancel()
-
 	clientConfig := &qmgo.Config{
 		Uri:         uri,
 		MaxPoolSize: &configureMongo.Env.PoolSize,
 	}
-	serverAPIOptions := opts.ServerAPI(opts.ServerAPIVersion1)
-
 	opt := opts.Client().SetAppName(configureMongo.Env.AppName)
-	opt.SetServerAPIOptions(serverAPIOptions)
-
-	// for monitoring pool
-	if configureMongo.Env.PoolMon == "yes" {
-		poolMonitor := &event.PoolMonitor{
-			Event: func(evt *event.PoolEvent) {
-				switch evt.Type {
-				case event.GetSucceeded:
-					fmt.Println("GetSucceeded")
-				case event.ConnectionReturned:
-					fmt.Println("ConnectionReturned")
-				}
-			},
-		}
-		opt.SetPoolMonitor(poolMonitor)
+
+	// the Stable API is not available on MongoDB < 5.0 and on
+	// compatible services like Amazon DocumentDB and Azure Cosmos DB
+	if configureMongo.Env.EnableServerAPI {
+		opt.SetServerAPIOptions(opts.ServerAPI(opts.ServerAPIVersion1))
 	}
 
-	client, err := qmgo.NewClient(ctx, clientConfig, options.ClientOptions{ClientOptions: opt})
+	if err := applyMongoConcerns(opt, configureMongo); err != nil {
+		return nil, err
+	}
+	applyMongoTimeouts(opt, configureMongo)
+	if err := applyMongoCompressors(opt, configureMongo); err != nil {
+		return nil, err
+	}
+
+	// for monitoring pool, the connections are always counted for the
+	// metrics, the checkouts are printed when PoolMon is enabled
+	poolMon := configureMongo.Env.PoolMon == "yes"
+	poolMonitor := &event.PoolMonitor{
+		Event: func(evt *event.PoolEvent) {
+			mongoPoolEvent(evt)
+
+			if !poolMon {
+				return
+			}
+			switch evt.Type {
+			case event.GetSucceeded:
+				fmt.Println("GetSucceeded")
+			case event.ConnectionReturned:
+				fmt.Println("ConnectionReturned")
+			}
+		},
+	}
+	opt.SetPoolMonitor(poolMonitor)
+
+	// the password of the URI must not appear in the logged errors
+	uriOptions := opts.Client().ApplyURI(uri)
+	var password string
+	if uriOptions.Auth != nil {
+		password = uriOptions.Auth.Password
+	}
+
+	var client *qmgo.Client
+	err := connectWithRetry(
+		context.Background(),
+		"mongo",
+		configureMongo.Env.MaxRetries,
+		configureMongo.Env.RetryDelay,
+		configureMongo.Env.RetryMaxDelay,
+		withRedaction(uri, password, func() (err error) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(configureMongo.Env.ConnTTL)*time.Second)
+			defer cancel()
+
+			// qmgo.NewClient connects with mongo.Connect (not the deprecated
+			// mongo.NewClient + Client.Connect) and pings the primary, both
+			// bounded by the deadline of ctx
+			client, err = qmgo.NewClient(ctx, clientConfig, options.ClientOptions{ClientOptions: opt})
+			return
+		}),
+	)
 	if err != nil {
 		return client, err
 	}
 
-	// Only for debugging
-	fmt.Println("MongoDB pool connection successful!")
+	log.WithFields(log.Fields{
+		"hosts":   strings.Join(uriOptions.Hosts, ","),
+		"appName": configureMongo.Env.AppName,
+	}).Info("mongo connection successful")
 
-	mongoClient = client
+	setMongo(client)
 
-	return mongoClient, nil
+	return client, nil
 }
 
 // GetMongo - get a connection
+//
+// It returns nil when mongo is not initialized, e.g. because
+// ACTIVATE_MONGO is not set to yes.
+//
+// See LazyInit to initialize the client on the first call.
 func GetMongo() *qmgo.Client {
+	client := currentMongo()
+	if client != nil || !LazyInit {
+		return client
+	}
+
+	lazyMongoMu.Lock()
+	defer lazyMongoMu.Unlock()
+
+	if client = currentMongo(); client != nil {
+		return client
+	}
+	if config.GetConfig() == nil || !config.IsMongo() {
+		return nil
+	}
+	client, err := InitMongo()
+	if err != nil {
+		log.WithError(err).Error("mongo: lazy initialization failed")
+		return nil
+	}
+
+	return client
+}
+
+// currentMongo - the mongo client, nil if not initialized
+func currentMongo() *qmgo.Client {
+	mongoMu.RLock()
+	defer mongoMu.RUnlock()
+
 	return mongoClient
 }
+
+// setMongo - replace the mongo client
+func setMongo(client *qmgo.Client) {
+	mongoMu.Lock()
+	defer mongoMu.Unlock()
+
+	mongoClient = client
+}
+
+// GetMongoDB - get the default database set in MONGO_DATABASE
+//
+// It returns nil when the client is not initialized or when no
+// default database is configured.
+func GetMongoDB() *qmgo.Database {
+	client := GetMongo()
+	if client == nil {
+		return nil
+	}
+
+	name := config.GetConfig().Database.MongoDB.Env.DatabaseName
+	if name == "" {
+		log.Warn("mongo: MONGO_DATABASE is not set, no default database")
+		return nil
+	}
+
+	return client.Database(name)
+}
+
+// DisconnectMongo - disconnect the mongo client and stop the
+// background monitors of the driver
+//
+// If ctx has no deadline, the connection TTL of the mongo config is
+// used as timeout. It is safe to call DisconnectMongo multiple times.
+func DisconnectMongo(ctx context.Context) error {
+	mongoMu.Lock()
+	client := mongoClient
+	mongoClient = nil
+	mongoMu.Unlock()
+
+	if client == nil {
+		return nil
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		connTTL := config.GetConfig().Database.MongoDB.Env.ConnTTL
+		if connTTL > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(connTTL)*time.Second)
+			defer cancel()
+		}
+	}
+
+	if err := client.Close(ctx); err != nil {
+		return fmt.Errorf("mongo: failed to disconnect client: %w", err)
+	}
+
+	return nil
+}
+
+// CloseMongo - disconnect the mongo client, same as DisconnectMongo
+func CloseMongo(ctx context.Context) error {
+	return DisconnectMongo(ctx)
+}
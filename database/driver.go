@@ -0,0 +1,53 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// drivers of the relational databases, values of DBDRIVER
+const (
+	DriverMySQL      = "mysql"
+	DriverPostgres   = "postgres"
+	DriverSQLite     = "sqlite3"
+	DriverSQLServer  = "sqlserver"
+	DriverClickHouse = "clickhouse"
+	// DriverCockroachDB - CockroachDB, connected with the driver of
+	// postgres
+	DriverCockroachDB = "cockroachdb"
+)
+
+// Driver - driver of the default connection, one of the Driver
+// constants, empty if not initialized
+//
+//	if database.Driver() == database.DriverPostgres {
+//		// COPY FROM with pgx
+//	}
+func Driver() string {
+	db := GetDB()
+	if db == nil {
+		return ""
+	}
+	// the dialector of postgres is named postgres
+	if isCockroachDB(db) {
+		return DriverCockroachDB
+	}
+	dialector := db.Dialector
+
+	// the sqlite dialector of GORM is named sqlite
+	if name := dialector.Name(); name != "sqlite" {
+		return name
+	}
+
+	return DriverSQLite
+}
+
+// Dialector - GORM dialector of the default connection, e.g. to reach
+// the driver-specific configuration, nil if not initialized
+func Dialector() gorm.Dialector {
+	db := GetDB()
+	if db == nil {
+		return nil
+	}
+
+	return db.Dialector
+}
@@ -0,0 +1,24 @@
+package database
+
+import (
+	"testing"
+)
+
+func TestDriver(t *testing.T) {
+	_ = CloseDB()
+	if Driver() != "" || Dialector() != nil {
+		t.Error("expected no driver without connection")
+	}
+
+	if _, err := InitTestDB(); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+
+	if got := Driver(); got != DriverSQLite {
+		t.Errorf("expected driver %s, got %s", DriverSQLite, got)
+	}
+	if Dialector() == nil || Dialector() != GetDB().Dialector {
+		t.Error("expected the dialector of the default connection")
+	}
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// PrintEffectiveDSN - DSN of the default connection as InitDB builds
+// it from the config and DSNEnvVar, without connecting to the database
+//
+// With mask, the password is replaced by asterisks. The credentials of
+// SetCredentialProvider and SetPasswordProvider are not fetched, the
+// DSN contains the password of the config. For sqlite3, the path of
+// the database is returned.
+//
+// Useful to compare the DSN with the one used by psql or mysql:
+//
+//	dsn, err := database.PrintEffectiveDSN(true)
+func PrintEffectiveDSN(mask bool) (string, error) {
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return "", errors.New("config is not loaded")
+	}
+	if rdbmsDeactivated(cfg) {
+		return "", &NotActivatedError{Datastore: "rdbms"}
+	}
+
+	dsn, err := effectiveDSN(cfg.Database.RDBMS, envDSNOptions()...)
+	if err != nil {
+		return "", err
+	}
+	if mask {
+		return maskDSN(dsn), nil
+	}
+
+	return dsn, nil
+}
+
+// effectiveDSN - DSN built by openDB for configureDB and opts
+func effectiveDSN(configureDB config.RDBMS, opts ...DBOption) (string, error) {
+	var o dbOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.dsn != "" {
+		return o.dsn, nil
+	}
+
+	configureDB = withForceUTC(configureDB)
+	if err := validateRDBMSConfig(configureDB); err != nil {
+		return "", fmt.Errorf("error code: 164: %w", err)
+	}
+	if configureDB.Access.URL != "" {
+		if err := validateURLScheme(configureDB.Env.Driver, configureDB.Access.URL); err != nil {
+			return "", fmt.Errorf("error code: 158: %w", err)
+		}
+	}
+
+	switch configureDB.Env.Driver {
+	case DriverMySQL:
+		return buildMySQLDSN(configureDB)
+	case DriverPostgres, DriverCockroachDB:
+		return buildPostgresDSN(configureDB)
+	case DriverSQLite:
+		return buildSQLitePath(configureDB), nil
+	case DriverSQLServer:
+		return sqlServerDSN(configureDB), nil
+	default:
+		return clickHouseDSN(configureDB), nil
+	}
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// DefaultSlowThreshold - queries taking longer are logged at Warn level
+const DefaultSlowThreshold = 200 * time.Millisecond
+
+// GormLogger - adapter forwarding the logs of GORM to logrus
+//
+// The SQL statements, slow queries and errors are logged with the
+// fields sql, rows, elapsed and source:
+//
+// - Error: failed queries (except gorm.ErrRecordNotFound)
+//
+// - Warn: queries slower than SlowThreshold
+//
+// - Info: all queries
+type GormLogger struct {
+	// Logger - logrus instance, the standard logger when nil
+	Logger *log.Logger
+	// LogLevel - level of GORM, logger.Silent disables all logs
+	LogLevel logger.LogLevel
+	// SlowThreshold - 0 disables the slow query logs
+	SlowThreshold time.Duration
+	// IgnoreRecordNotFoundError - do not log gorm.ErrRecordNotFound
+	IgnoreRecordNotFoundError bool
+}
+
+// NewGormLogger - create a GORM logger backed by the standard logrus
+// logger with the default slow query threshold
+func NewGormLogger(level logger.LogLevel) *GormLogger {
+	return &GormLogger{
+		Logger:                    log.StandardLogger(),
+		LogLevel:                  level,
+		SlowThreshold:             DefaultSlowThreshold,
+		IgnoreRecordNotFoundError: true,
+	}
+}
+
+// newGormLogger - create a GORM logger with the given slow query
+// threshold
+func newGormLogger(level logger.LogLevel, slowThreshold time.Duration) *GormLogger {
+	gormLogger := NewGormLogger(level)
+	gormLogger.SlowThreshold = slowThreshold
+
+	return gormLogger
+}
+
+// gormLogLevel - GORM level of the configured DBLOGLEVEL
+//
+// 0 (not set) is silent. A level outside of 1 (silent) to 4 (info) is
+// clamped to the nearest valid level with a warning.
+func gormLogLevel(level int) logger.LogLevel {
+	switch {
+	case level == 0:
+		return logger.Silent
+	case level < int(logger.Silent):
+		log.WithField("level", level).Warn("database: DBLOGLEVEL is out of range [1, 4], using 1 (silent)")
+		return logger.Silent
+	case level > int(logger.Info):
+		log.WithField("level", level).Warn("database: DBLOGLEVEL is out of range [1, 4], using 4 (info)")
+		return logger.Info
+	}
+
+	return logger.LogLevel(level)
+}
+
+// LogMode - return a copy of the logger with the given level
+func (l *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	newLogger := *l
+	newLogger.LogLevel = level
+
+	return &newLogger
+}
+
+// Info - log at Info level
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.LogLevel >= logger.Info {
+		l.entry(ctx).Infof(msg, data...)
+	}
+}
+
+// Warn - log at Warn level
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.LogLevel >= logger.Warn {
+		l.entry(ctx).Warnf(msg, data...)
+	}
+}
+
+// Error - log at Error level
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.LogLevel >= logger.Error {
+		l.entry(ctx).Errorf(msg, data...)
+	}
+}
+
+// Trace - log an executed SQL statement
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.LogLevel <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+
+	fields := func() log.Fields {
+		sql, rows := fc()
+		return log.Fields{
+			"sql":     sql,
+			"rows":    rows,
+			"elapsed": elapsed.String(),
+			"source":  utils.FileWithLineNum(),
+		}
+	}
+
+	switch {
+	case err != nil && l.LogLevel >= logger.Error && (!errors.Is(err, gorm.ErrRecordNotFound) || !l.IgnoreRecordNotFoundError):
+		l.entry(ctx).WithFields(fields()).WithError(err).Error("sql error")
+	case l.SlowThreshold != 0 && elapsed > l.SlowThreshold && l.LogLevel >= logger.Warn:
+		l.entry(ctx).WithFields(fields()).Warn(fmt.Sprintf("slow sql >= %v", l.SlowThreshold))
+	case l.LogLevel >= logger.Info:
+		l.entry(ctx).WithFields(fields()).Info("sql")
+	}
+}
+
+// entry - logrus entry bound to ctx
+func (l *GormLogger) entry(ctx context.Context) *log.Entry {
+	logrusLogger := l.Logger
+	if logrusLogger == nil {
+		logrusLogger = log.StandardLogger()
+	}
+
+	return logrusLogger.WithContext(ctx)
+}
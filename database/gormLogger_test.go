@@ -0,0 +1,147 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openLoggedDB - open a sqlite database logging into buf
+func openLoggedDB(t *testing.T, buf *bytes.Buffer, gormLogger *GormLogger) *gorm.DB {
+	logrusLogger := log.New()
+	logrusLogger.SetOutput(buf)
+	logrusLogger.SetFormatter(&log.JSONFormatter{})
+	logrusLogger.SetLevel(log.DebugLevel)
+	gormLogger.Logger = logrusLogger
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "log.db")), &gorm.Config{
+		Logger: gormLogger,
+	})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+
+	return db
+}
+
+// logEntries - decode the JSON log entries in buf
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log entry %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestGormLoggerInfo(t *testing.T) {
+	var buf bytes.Buffer
+	db := openLoggedDB(t, &buf, NewGormLogger(logger.Info))
+
+	if err := db.Exec("SELECT 1").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	entries := logEntries(t, &buf)
+	if len(entries) == 0 {
+		t.Fatal("expected the query to be logged")
+	}
+	entry := entries[len(entries)-1]
+	if entry["level"] != "info" || entry["sql"] != "SELECT 1" {
+		t.Errorf("unexpected entry %v", entry)
+	}
+	for _, field := range []string{"rows", "elapsed", "source"} {
+		if _, ok := entry[field]; !ok {
+			t.Errorf("missing field %s in %v", field, entry)
+		}
+	}
+}
+
+func TestGormLoggerError(t *testing.T) {
+	var buf bytes.Buffer
+	db := openLoggedDB(t, &buf, NewGormLogger(logger.Error))
+
+	if err := db.Exec("SELECT 1").Error; err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("successful queries must not be logged at Error level: %s", buf.String())
+	}
+
+	if err := db.Exec("SELECT * FROM missing_table").Error; err == nil {
+		t.Fatal("expected an error")
+	}
+	entries := logEntries(t, &buf)
+	if len(entries) != 1 || entries[0]["level"] != "error" || entries[0]["error"] == nil {
+		t.Errorf("expected one error entry, got %v", entries)
+	}
+}
+
+func TestGormLoggerSlowQuery(t *testing.T) {
+	var buf bytes.Buffer
+	gormLogger := NewGormLogger(logger.Warn)
+	gormLogger.SlowThreshold = time.Nanosecond
+	db := openLoggedDB(t, &buf, gormLogger)
+
+	if err := db.Exec("SELECT 1").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	entries := logEntries(t, &buf)
+	if len(entries) != 1 || entries[0]["level"] != "warning" || entries[0]["sql"] != "SELECT 1" {
+		t.Errorf("expected one slow query entry, got %v", entries)
+	}
+}
+
+func TestGormLoggerSilent(t *testing.T) {
+	var buf bytes.Buffer
+	db := openLoggedDB(t, &buf, NewGormLogger(logger.Silent))
+
+	_ = db.Exec("SELECT * FROM missing_table").Error
+	if buf.Len() != 0 {
+		t.Errorf("expected no logs, got %s", buf.String())
+	}
+}
+
+func TestGormLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	testCases := []struct {
+		level int
+		want  logger.LogLevel
+		warn  bool
+	}{
+		{level: 0, want: logger.Silent},
+		{level: 1, want: logger.Silent},
+		{level: 4, want: logger.Info},
+		{level: -1, want: logger.Silent, warn: true},
+		{level: 9, want: logger.Info, warn: true},
+	}
+	for _, tc := range testCases {
+		buf.Reset()
+		if got := gormLogLevel(tc.level); got != tc.want {
+			t.Errorf("%d: expected level %d, got %d", tc.level, tc.want, got)
+		}
+		if warned := strings.Contains(buf.String(), "out of range"); warned != tc.warn {
+			t.Errorf("%d: expected warning %v, got %q", tc.level, tc.warn, buf.String())
+		}
+	}
+}
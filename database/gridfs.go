@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/qiniu/qmgo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetGridFSBucket - GridFS bucket of the default database
+// (MONGO_DATABASE), bucketName "" selects the default bucket "fs"
+//
+// The files of the bucket are stored in the collections
+// <bucketName>.files and <bucketName>.chunks.
+func GetGridFSBucket(bucketName string) (*gridfs.Bucket, error) {
+	db := GetMongoDB()
+	if db == nil {
+		return nil, ErrMongoNotInitialized
+	}
+
+	return newGridFSBucket(db, bucketName)
+}
+
+// newGridFSBucket - GridFS bucket of db
+func newGridFSBucket(db *qmgo.Database, bucketName string) (*gridfs.Bucket, error) {
+	if bucketName == "" {
+		bucketName = options.DefaultName
+	}
+
+	// the bucket requires the database of the driver
+	coll, err := db.Collection(bucketName + ".files").CloneCollection()
+	if err != nil {
+		return nil, fmt.Errorf("mongo: %w", err)
+	}
+
+	return gridfs.NewBucket(coll.Database(), options.GridFSBucket().SetName(bucketName))
+}
+
+// gridFSBucket - bucket with the read and write deadlines of ctx
+func gridFSBucket(ctx context.Context, bucketName string) (*gridfs.Bucket, error) {
+	bucket, err := GetGridFSBucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	// zero time without deadline
+	deadline, _ := ctx.Deadline()
+	if err := bucket.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if err := bucket.SetWriteDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	return bucket, nil
+}
+
+// UploadFile - store the content of source as filename in the bucket
+// and return the ID of the new file
+//
+// Uploading the same filename again adds a new revision, the
+// downloads by name return the latest one.
+func UploadFile(ctx context.Context, bucketName, filename string, source io.Reader) (primitive.ObjectID, error) {
+	bucket, err := gridFSBucket(ctx, bucketName)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return bucket.UploadFromStream(filename, source)
+}
+
+// DownloadFile - write the content of the file with the given ID to w
+// and return the number of bytes written
+//
+// gridfs.ErrFileNotFound is returned when the file does not exist.
+func DownloadFile(ctx context.Context, bucketName string, fileID interface{}, w io.Writer) (int64, error) {
+	bucket, err := gridFSBucket(ctx, bucketName)
+	if err != nil {
+		return 0, err
+	}
+
+	return bucket.DownloadToStream(fileID, w)
+}
+
+// DownloadFileByName - write the content of the latest revision of
+// filename to w and return the number of bytes written
+//
+// gridfs.ErrFileNotFound is returned when the file does not exist.
+func DownloadFileByName(ctx context.Context, bucketName, filename string, w io.Writer) (int64, error) {
+	bucket, err := gridFSBucket(ctx, bucketName)
+	if err != nil {
+		return 0, err
+	}
+
+	return bucket.DownloadToStreamByName(filename, w)
+}
+
+// DeleteFile - delete the file with the given ID and its chunks
+//
+// gridfs.ErrFileNotFound is returned when the file does not exist.
+func DeleteFile(ctx context.Context, bucketName string, fileID interface{}) error {
+	bucket, err := gridFSBucket(ctx, bucketName)
+	if err != nil {
+		return err
+	}
+
+	return bucket.DeleteContext(ctx, fileID)
+}
+
+// DeleteFileByName - delete all revisions of filename and return the
+// number of deleted files
+func DeleteFileByName(ctx context.Context, bucketName, filename string) (int, error) {
+	bucket, err := gridFSBucket(ctx, bucketName)
+	if err != nil {
+		return 0, err
+	}
+
+	cursor, err := bucket.FindContext(ctx, bson.M{"filename": filename})
+	if err != nil {
+		return 0, err
+	}
+	var files []struct {
+		ID interface{} `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &files); err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, file := range files {
+		if err := bucket.DeleteContext(ctx, file.ID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
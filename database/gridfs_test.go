@@ -0,0 +1,26 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGridFSNotInitialized(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := GetGridFSBucket(""); !errors.Is(err, ErrMongoNotInitialized) {
+		t.Errorf("expected ErrMongoNotInitialized, got %v", err)
+	}
+	if _, err := UploadFile(ctx, "", "a.txt", strings.NewReader("a")); !errors.Is(err, ErrMongoNotInitialized) {
+		t.Errorf("expected ErrMongoNotInitialized, got %v", err)
+	}
+	if _, err := DownloadFileByName(ctx, "", "a.txt", &bytes.Buffer{}); !errors.Is(err, ErrMongoNotInitialized) {
+		t.Errorf("expected ErrMongoNotInitialized, got %v", err)
+	}
+	if err := DeleteFile(ctx, "", "id"); !errors.Is(err, ErrMongoNotInitialized) {
+		t.Errorf("expected ErrMongoNotInitialized, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mediocregopher/radix/v4"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// HealthCheck - verify that all initialized databases are reachable
+//
+// The RDBMS, Redis and MongoDB are pinged concurrently, so a slow
+// backend does not delay the checks of the others. Each ping honors
+// the deadline of ctx. Databases that have not been initialized are
+// skipped. The returned error combines the errors of all unhealthy
+// backends.
+func HealthCheck(ctx context.Context) error {
+	var checks []func(context.Context) error
+
+	if currentDB() != nil {
+		checks = append(checks, pingRDBMS)
+	}
+	if currentRedis() != nil {
+		checks = append(checks, pingRedis)
+	}
+	if currentMongo() != nil {
+		checks = append(checks, pingMongo)
+	}
+
+	errs := make([]error, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check func(context.Context) error) {
+			defer wg.Done()
+			errs[i] = check(ctx)
+		}(i, check)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// pingRDBMS - ping the relational database
+func pingRDBMS(ctx context.Context) error {
+	client := currentDB()
+	if client == nil {
+		// closed after HealthCheck selected the checks
+		return nil
+	}
+	db, err := client.DB()
+	if err != nil {
+		return fmt.Errorf("rdbms: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("rdbms: %w", err)
+	}
+
+	return nil
+}
+
+// pingRedis - send PING to the redis server
+func pingRedis(ctx context.Context) error {
+	current := currentRedis()
+	if current == nil {
+		return nil
+	}
+	client := *current
+	if err := client.Do(ctx, radix.Cmd(nil, "PING")); err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+
+	return nil
+}
+
+// pingMongo - run the ping command on the mongo deployment
+func pingMongo(ctx context.Context) error {
+	client := currentMongo()
+	if client == nil {
+		return nil
+	}
+	res := client.Database("admin").RunCommand(ctx, bson.D{{Key: "ping", Value: 1}})
+	if err := res.Err(); err != nil {
+		return fmt.Errorf("mongo: %w", err)
+	}
+
+	return nil
+}
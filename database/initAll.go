@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	opts "go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// InitAll - initialize the databases activated in cfg
+// (ACTIVATE_RDBMS, ACTIVATE_REDIS, ACTIVATE_MONGO) in this order
+//
+// It stops at the first error. After all activated databases are
+// connected, one summary with the driver, the host and the pool size
+// of each database is logged.
+//
+// The mongo indexes declared with RegisterMongoIndexes are created in
+// MONGO_DATABASE right after the connection.
+func InitAll(cfg *config.Configuration) error {
+	if cfg == nil {
+		return errors.New("config is not loaded")
+	}
+	ctx := context.Background()
+	summary := log.Fields{}
+
+	configureDB := cfg.Database.RDBMS
+	if configureDB.Activate == config.Activated {
+		db, err := initDB(ctx, configureDB)
+		if err != nil {
+			return fmt.Errorf("rdbms: %w", err)
+		}
+		summary["rdbms.driver"] = configureDB.Env.Driver
+		summary["rdbms.host"] = rdbmsHost(configureDB, envDSNOptions()...)
+		if sqlDB, err := db.DB(); err == nil {
+			// 0: unlimited
+			summary["rdbms.pool"] = sqlDB.Stats().MaxOpenConnections
+		}
+	} else {
+		summary["rdbms"] = "disabled"
+	}
+
+	configureRedis := cfg.Database.REDIS
+	if configureRedis.Activate == config.Activated {
+		if _, err := initRedis(ctx, configureRedis); err != nil {
+			return fmt.Errorf("redis: %w", err)
+		}
+		summary["redis.host"] = redisHost(configureRedis)
+		summary["redis.pool"] = configureRedis.Conn.PoolSize
+	} else {
+		summary["redis"] = "disabled"
+	}
+
+	configureMongo := cfg.Database.MongoDB
+	if configureMongo.Activate == config.Activated {
+		client, err := initMongo(configureMongo)
+		if err != nil {
+			return fmt.Errorf("mongo: %w", err)
+		}
+		if name := configureMongo.Env.DatabaseName; name != "" {
+			if err := ensureRegisteredMongoIndexes(ctx, client.Database(name)); err != nil {
+				return err
+			}
+		}
+		summary["mongo.host"] = strings.Join(opts.Client().ApplyURI(configureMongo.Env.URI).Hosts, ",")
+		summary["mongo.pool"] = configureMongo.Env.PoolSize
+	} else {
+		summary["mongo"] = "disabled"
+	}
+
+	log.WithFields(summary).Info("datastores initialized")
+
+	return nil
+}
+
+// rdbmsHost - host of the relational database for the logs, without
+// credentials, the DSN of opts replaces the config
+func rdbmsHost(configureDB config.RDBMS, opts ...DBOption) string {
+	var o dbOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	switch {
+	case o.dsn != "":
+		return maskDSN(o.dsn)
+	case configureDB.Env.Driver == DriverSQLite:
+		return configureDB.Access.DbName
+	case configureDB.Access.URL != "":
+		// the password is not logged
+		return maskDSN(configureDB.Access.URL)
+	case configureDB.Env.Socket != "":
+		return configureDB.Env.Socket
+	case configureDB.Env.Port != "":
+		return configureDB.Env.Host + ":" + configureDB.Env.Port
+	}
+
+	return configureDB.Env.Host
+}
+
+// redisHost - address of redis for the logs
+func redisHost(configureRedis config.REDIS) string {
+	if len(configureRedis.Cluster.Addrs) > 0 {
+		return strings.Join(configureRedis.Cluster.Addrs, ",")
+	}
+	if configureRedis.Sentinel.MasterName != "" && len(configureRedis.Sentinel.Addrs) > 0 {
+		return configureRedis.Sentinel.MasterName + "@" + strings.Join(configureRedis.Sentinel.Addrs, ",")
+	}
+
+	return configureRedis.Env.Host + ":" + configureRedis.Env.Port
+}
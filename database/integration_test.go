@@ -0,0 +1,284 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+
+	"github.com/pilinux/gorest/config"
+	"github.com/pilinux/gorest/database/testhelpers"
+)
+
+// integration tests against real databases, they are skipped when
+// Docker is not available or with -short
+
+type integrationItem struct {
+	ID   uint
+	Name string
+}
+
+func testIntegrationRDBMS(t *testing.T, cfg config.RDBMS) {
+	db, err := InitNamedDB(DefaultDBName, cfg)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer CloseDB()
+
+	if GetDB() != db {
+		t.Fatal("expected the connection to be the default connection")
+	}
+	if err := db.AutoMigrate(&integrationItem{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := db.Create(&integrationItem{Name: "gorest"}).Error; err != nil {
+		t.Fatalf("failed to create: %v", err)
+	}
+	var item integrationItem
+	if err := db.Where("name = ?", "gorest").First(&item).Error; err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if err := HealthCheck(context.Background()); err != nil {
+		t.Errorf("health check failed: %v", err)
+	}
+
+	testUpsert(t, db)
+	testCaseInsensitiveLike(t, db)
+	if db.Dialector.Name() == DriverPostgres {
+		testWithSchema(t, db)
+		testIsolationLevel(t)
+	}
+
+	err = WithReadOnlyTransaction(context.Background(), func(tx *gorm.DB) error {
+		return tx.Create(&integrationItem{Name: "read-only"}).Error
+	})
+	if err == nil {
+		t.Error("expected the write to fail in a read-only transaction")
+	}
+}
+
+func TestIntegrationMySQL(t *testing.T) {
+	testIntegrationRDBMS(t, testhelpers.MySQL(t))
+}
+
+func TestIntegrationPostgres(t *testing.T) {
+	testIntegrationRDBMS(t, testhelpers.Postgres(t))
+}
+
+func TestIntegrationPostgresTimeZone(t *testing.T) {
+	cfg := testhelpers.Postgres(t)
+	cfg.Env.TimeZone = "Asia/Tokyo"
+	db, err := InitNamedDB(DefaultDBName, cfg)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer CloseDB()
+
+	var timeZone string
+	if err := db.Raw("SHOW timezone").Scan(&timeZone).Error; err != nil {
+		t.Fatalf("failed to read the time zone: %v", err)
+	}
+	if timeZone != "Asia/Tokyo" {
+		t.Errorf("expected Asia/Tokyo, got %q", timeZone)
+	}
+}
+
+func TestIntegrationCockroachDB(t *testing.T) {
+	cfg := testhelpers.CockroachDB(t)
+	testIntegrationRDBMS(t, cfg)
+
+	if _, err := InitNamedDB(DefaultDBName, cfg); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer CloseDB()
+
+	if got := Driver(); got != DriverCockroachDB {
+		t.Errorf("expected driver %s, got %s", DriverCockroachDB, got)
+	}
+	if version, err := ServerVersion(context.Background()); err != nil || !strings.HasPrefix(version, "v") {
+		t.Errorf("expected the version of cockroachdb, got %q: %v", version, err)
+	}
+
+	// the first attempt fails with a serialization failure of the server
+	attempts := 0
+	err := WithTransaction(context.Background(), func(tx *gorm.DB) error {
+		attempts++
+		if attempts == 1 {
+			return tx.Exec("SELECT crdb_internal.force_retry('1h')").Error
+		}
+		return tx.Create(&integrationItem{Name: "retried"}).Error
+	})
+	if err != nil || attempts != 2 {
+		t.Errorf("expected the transaction to succeed on the second attempt, got %d attempts: %v", attempts, err)
+	}
+}
+
+func TestIntegrationRedis(t *testing.T) {
+	cfg := testhelpers.Redis(t)
+
+	if _, err := initRedis(context.Background(), cfg); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer CloseRedis()
+
+	ctx := context.Background()
+	if err := CacheSet(ctx, "key", []byte("value"), time.Minute); err != nil {
+		t.Fatalf("failed to set: %v", err)
+	}
+	val, ok, err := CacheGet(ctx, "key")
+	if err != nil || !ok || string(val) != "value" {
+		t.Errorf("expected value, got %q, %v, %v", val, ok, err)
+	}
+}
+
+func TestIntegrationMongo(t *testing.T) {
+	cfg := testhelpers.MongoDB(t)
+
+	if _, err := initMongo(cfg); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = DisconnectMongo(ctx)
+	}()
+
+	if err := HealthCheck(context.Background()); err != nil {
+		t.Errorf("health check failed: %v", err)
+	}
+
+	models := []mongo.IndexModel{{Keys: bson.D{{Key: "email", Value: 1}}}}
+	for i := 0; i < 2; i++ {
+		if err := ensureMongoIndexes(context.Background(), GetMongo().Database(cfg.Env.DatabaseName), "users", models); err != nil {
+			t.Fatalf("failed to ensure the indexes: %v", err)
+		}
+	}
+
+	bucket, err := newGridFSBucket(GetMongo().Database(cfg.Env.DatabaseName), "")
+	if err != nil {
+		t.Fatalf("failed to open the bucket: %v", err)
+	}
+	fileID, err := bucket.UploadFromStream("hello.txt", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("failed to upload: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := bucket.DownloadToStreamByName("hello.txt", &buf); err != nil || buf.String() != "hello" {
+		t.Errorf("expected hello, got %q: %v", buf.String(), err)
+	}
+	if err := bucket.Delete(fileID); err != nil {
+		t.Errorf("failed to delete: %v", err)
+	}
+
+	orders := GetMongo().Database(cfg.Env.DatabaseName).Collection("orders")
+	_, err = orders.InsertMany(context.Background(), []bson.M{
+		{"customer": "a", "amount": 10},
+		{"customer": "b", "amount": 5},
+		{"customer": "a", "amount": 20},
+	})
+	if err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$customer", "total": bson.M{"$sum": "$amount"}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	var totals []struct {
+		Customer string `bson:"_id"`
+		Total    int    `bson:"total"`
+	}
+	if err := aggregate(context.Background(), GetMongo().Database(cfg.Env.DatabaseName), "orders", pipeline, &totals); err != nil {
+		t.Fatalf("failed to aggregate: %v", err)
+	}
+	if len(totals) != 2 || totals[0].Customer != "a" || totals[0].Total != 30 || totals[1].Customer != "b" || totals[1].Total != 5 {
+		t.Errorf("unexpected totals %+v", totals)
+	}
+
+	err = WithMongoTransaction(context.Background(), func(mongo.SessionContext) error {
+		return nil
+	})
+	if !errors.Is(err, ErrMongoTransactionNotSupported) {
+		t.Errorf("expected ErrMongoTransactionNotSupported on a standalone server, got %v", err)
+	}
+	if _, err := watchCollection(context.Background(), GetMongo(), GetMongo().Database(cfg.Env.DatabaseName), "users", nil); !errors.Is(err, ErrMongoChangeStreamNotSupported) {
+		t.Errorf("expected ErrMongoChangeStreamNotSupported on a standalone server, got %v", err)
+	}
+}
+
+func TestIntegrationMongoTransaction(t *testing.T) {
+	cfg := testhelpers.MongoDBReplicaSet(t)
+
+	client, err := initMongo(cfg)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = DisconnectMongo(ctx)
+	}()
+
+	ctx := context.Background()
+	coll := client.Database(cfg.Env.DatabaseName).Collection("transactions")
+	// collections can not be created inside a transaction before 4.4
+	if _, err := coll.InsertOne(ctx, bson.M{"name": "setup"}); err != nil {
+		t.Fatalf("failed to create collection: %v", err)
+	}
+
+	err = WithMongoTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		if _, err := coll.InsertOne(sessCtx, bson.M{"name": "committed"}); err != nil {
+			return err
+		}
+		_, err := coll.InsertOne(sessCtx, bson.M{"name": "committed"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	errAbort := errors.New("abort")
+	err = WithMongoTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		if _, err := coll.InsertOne(sessCtx, bson.M{"name": "aborted"}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+
+	if n, err := coll.Find(ctx, bson.M{"name": "committed"}).Count(); err != nil || n != 2 {
+		t.Errorf("expected 2 committed documents, got %d, %v", n, err)
+	}
+	if n, err := coll.Find(ctx, bson.M{"name": "aborted"}).Count(); err != nil || n != 0 {
+		t.Errorf("expected no aborted document, got %d, %v", n, err)
+	}
+
+	watchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"operationType": "insert"}}}}
+	events, err := watchCollection(watchCtx, client, client.Database(cfg.Env.DatabaseName), "transactions", pipeline)
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	if _, err := coll.InsertOne(ctx, bson.M{"name": "watched"}); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	select {
+	case event := <-events:
+		if doc, _ := event["fullDocument"].(bson.M); doc["name"] != "watched" {
+			t.Errorf("unexpected change event %v", event)
+		}
+	case <-watchCtx.Done():
+		t.Fatal("expected a change event")
+	}
+	cancel()
+	for range events {
+	}
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/mediocregopher/radix/v4"
+)
+
+// ErrLockNotHeld - the lock expired or is held by another owner
+var ErrLockNotHeld = errors.New("redis: lock is not held")
+
+// releaseLockScript - delete the lock only when it still holds the
+// token of the owner
+var releaseLockScript = radix.NewEvalScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// AcquireLock - try to acquire the distributed lock key for ttl
+//
+// ok is false when another owner holds the lock. The returned token
+// identifies the owner and must be passed to ReleaseLock. The lock
+// expires after ttl even if it is not released, e.g. when the process
+// crashes, so ttl must cover the duration of the guarded work. The key
+// is prefixed with CacheKeyPrefix.
+//
+//	token, ok, err := database.AcquireLock(ctx, "cron:cleanup", time.Minute)
+//	if err != nil || !ok {
+//		return
+//	}
+//	defer database.ReleaseLock(ctx, "cron:cleanup", token)
+func AcquireLock(ctx context.Context, key string, ttl time.Duration) (token string, ok bool, err error) {
+	if ttl <= 0 {
+		return "", false, errors.New("lock ttl must be greater than 0")
+	}
+
+	client, err := cacheClient()
+	if err != nil {
+		return "", false, err
+	}
+
+	b := make([]byte, 16)
+	if _, err = rand.Read(b); err != nil {
+		return "", false, err
+	}
+	token = hex.EncodeToString(b)
+
+	var reply string
+	mb := radix.Maybe{Rcv: &reply}
+	err = client.Do(ctx, radix.Cmd(&mb, "SET", cacheKey(key), token, "NX", "PX", redisMillis(ttl)))
+	if err != nil {
+		return "", false, err
+	}
+	if mb.Null {
+		return "", false, nil
+	}
+
+	return token, true, nil
+}
+
+// ReleaseLock - release the lock key acquired with token
+//
+// The lock is only deleted when it is still held by the owner of
+// token, otherwise ErrLockNotHeld is returned.
+func ReleaseLock(ctx context.Context, key, token string) error {
+	client, err := cacheClient()
+	if err != nil {
+		return err
+	}
+
+	var deleted int
+	if err = client.Do(ctx, releaseLockScript.Cmd(&deleted, []string{cacheKey(key)}, token)); err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
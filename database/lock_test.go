@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	s := initTestRedis(t)
+	ctx := context.Background()
+
+	token, ok, err := AcquireLock(ctx, "job", time.Minute)
+	if err != nil || !ok || token == "" {
+		t.Fatalf("expected to acquire the lock, got %q, %v, %v", token, ok, err)
+	}
+	if _, ok, err := AcquireLock(ctx, "job", time.Minute); err != nil || ok {
+		t.Fatalf("expected the lock to be held, got %v, %v", ok, err)
+	}
+
+	// only the owner releases the lock
+	if err := ReleaseLock(ctx, "job", "other-token"); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("expected ErrLockNotHeld, got %v", err)
+	}
+	if err := ReleaseLock(ctx, "job", token); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReleaseLock(ctx, "job", token); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("expected ErrLockNotHeld after release, got %v", err)
+	}
+
+	// the lock expires after ttl
+	if _, ok, err := AcquireLock(ctx, "job", time.Second); err != nil || !ok {
+		t.Fatalf("expected to acquire the lock, got %v, %v", ok, err)
+	}
+	s.FastForward(2 * time.Second)
+	if _, ok, err := AcquireLock(ctx, "job", time.Second); err != nil || !ok {
+		t.Fatalf("expected to acquire the expired lock, got %v, %v", ok, err)
+	}
+
+	if _, _, err := AcquireLock(ctx, "job", 0); err == nil {
+		t.Error("expected error for a zero ttl")
+	}
+}
+
+func TestLockMutualExclusion(t *testing.T) {
+	initTestRedis(t)
+	ctx := context.Background()
+
+	var holders, maxHolders, acquired atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				token, ok, err := AcquireLock(ctx, "exclusive", time.Minute)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if !ok {
+					continue
+				}
+				acquired.Add(1)
+				n := holders.Add(1)
+				for {
+					current := maxHolders.Load()
+					if n <= current || maxHolders.CompareAndSwap(current, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				holders.Add(-1)
+				if err := ReleaseLock(ctx, "exclusive", token); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if acquired.Load() == 0 {
+		t.Fatal("expected the lock to be acquired")
+	}
+	if maxHolders.Load() != 1 {
+		t.Errorf("expected at most 1 holder, got %d", maxHolders.Load())
+	}
+}
@@ -0,0 +1,231 @@
+package database
+
+import (
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mediocregopher/radix/v4/trace"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MetricsScrapeInterval - interval between two reads of the connection
+// pool stats of the relational databases, set it before calling
+// RegisterDBMetrics
+var MetricsScrapeInterval = 15 * time.Second
+
+// connections of the redis and mongo pools, counted by the pool
+// trace of radix and the pool monitor of the mongo driver
+var (
+	redisPoolSize    atomic.Int64
+	redisConnsOpen   atomic.Int64
+	mongoConnsOpen   atomic.Int64
+	mongoConnsInUse  atomic.Int64
+	dbMetricsMu      sync.Mutex
+	dbMetricsStop    chan struct{}
+	dbMetricsStopped chan struct{}
+)
+
+// sqlMetrics - collectors of the sql.DBStats of all named connections
+type sqlMetrics struct {
+	maxOpen           *prometheus.GaugeVec
+	open              *prometheus.GaugeVec
+	inUse             *prometheus.GaugeVec
+	idle              *prometheus.GaugeVec
+	waitCount         *prometheus.CounterVec
+	waitDuration      *prometheus.CounterVec
+	maxIdleClosed     *prometheus.CounterVec
+	maxIdleTimeClosed *prometheus.CounterVec
+	maxLifetimeClosed *prometheus.CounterVec
+
+	// last stats of each connection, to add the increase to the counters
+	last map[string]sqlPoolSample
+}
+
+// sqlPoolSample - stats read from a pool, the stats of a new pool of
+// the same connection start from zero again
+type sqlPoolSample struct {
+	db    *sql.DB
+	stats sql.DBStats
+}
+
+// RegisterDBMetrics - register the connection pool metrics with reg
+//
+// The stats of all named RDBMS connections (label "name") are read
+// every MetricsScrapeInterval until CloseDB is called. The number of
+// open connections of the redis pools and the number of open and
+// in-use connections of the mongo pool are read on each scrape of
+// reg.
+//
+// Metrics:
+//
+// - gorest_db_max_open_connections, gorest_db_open_connections,
+// gorest_db_in_use_connections, gorest_db_idle_connections
+//
+// - gorest_db_wait_count_total, gorest_db_wait_duration_seconds_total,
+// gorest_db_max_idle_closed_total, gorest_db_max_idle_time_closed_total,
+// gorest_db_max_lifetime_closed_total
+//
+// - gorest_redis_pool_size, gorest_redis_open_connections
+//
+// - gorest_mongo_open_connections, gorest_mongo_in_use_connections
+func RegisterDBMetrics(reg prometheus.Registerer) error {
+	m := newSQLMetrics()
+
+	collectors := []prometheus.Collector{
+		m.maxOpen, m.open, m.inUse, m.idle,
+		m.waitCount, m.waitDuration, m.maxIdleClosed, m.maxIdleTimeClosed, m.maxLifetimeClosed,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "gorest", Subsystem: "redis", Name: "pool_size",
+			Help: "Configured size of the redis connection pool.",
+		}, func() float64 { return float64(redisPoolSize.Load()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "gorest", Subsystem: "redis", Name: "open_connections",
+			Help: "Number of open connections of the redis pools.",
+		}, func() float64 { return float64(redisConnsOpen.Load()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "gorest", Subsystem: "mongo", Name: "open_connections",
+			Help: "Number of open connections of the mongo pool.",
+		}, func() float64 { return float64(mongoConnsOpen.Load()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "gorest", Subsystem: "mongo", Name: "in_use_connections",
+			Help: "Number of connections of the mongo pool currently in use.",
+		}, func() float64 { return float64(mongoConnsInUse.Load()) }),
+	}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+
+	stopDBMetrics()
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	dbMetricsMu.Lock()
+	dbMetricsStop = stop
+	dbMetricsStopped = stopped
+	dbMetricsMu.Unlock()
+
+	m.scrape()
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(MetricsScrapeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				m.scrape()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// newSQLMetrics - collectors of the sql.DBStats, not registered
+func newSQLMetrics() *sqlMetrics {
+	labels := []string{"name"}
+	gauge := func(name, help string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: "gorest", Subsystem: "db", Name: name, Help: help}, labels)
+	}
+	counter := func(name, help string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: "gorest", Subsystem: "db", Name: name, Help: help}, labels)
+	}
+
+	return &sqlMetrics{
+		maxOpen:           gauge("max_open_connections", "Maximum number of open connections to the database."),
+		open:              gauge("open_connections", "Number of established connections, in use and idle."),
+		inUse:             gauge("in_use_connections", "Number of connections currently in use."),
+		idle:              gauge("idle_connections", "Number of idle connections."),
+		waitCount:         counter("wait_count_total", "Total number of connections waited for."),
+		waitDuration:      counter("wait_duration_seconds_total", "Total time blocked waiting for a new connection."),
+		maxIdleClosed:     counter("max_idle_closed_total", "Total number of connections closed due to SetMaxIdleConns."),
+		maxIdleTimeClosed: counter("max_idle_time_closed_total", "Total number of connections closed due to SetConnMaxIdleTime."),
+		maxLifetimeClosed: counter("max_lifetime_closed_total", "Total number of connections closed due to SetConnMaxLifetime."),
+		last:              make(map[string]sqlPoolSample),
+	}
+}
+
+// stopDBMetrics - stop reading the stats of the RDBMS connections
+func stopDBMetrics() {
+	dbMetricsMu.Lock()
+	stop := dbMetricsStop
+	stopped := dbMetricsStopped
+	dbMetricsStop = nil
+	dbMetricsStopped = nil
+	dbMetricsMu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-stopped
+	}
+}
+
+// scrape - read the stats of all named RDBMS connections
+func (m *sqlMetrics) scrape() {
+	dbRegistryMu.RLock()
+	samples := make(map[string]sqlPoolSample, len(dbRegistry))
+	for name, db := range dbRegistry {
+		if sqlDB, err := db.DB(); err == nil {
+			samples[name] = sqlPoolSample{db: sqlDB, stats: sqlDB.Stats()}
+		}
+	}
+	dbRegistryMu.RUnlock()
+
+	for name, sample := range samples {
+		s := sample.stats
+		m.maxOpen.WithLabelValues(name).Set(float64(s.MaxOpenConnections))
+		m.open.WithLabelValues(name).Set(float64(s.OpenConnections))
+		m.inUse.WithLabelValues(name).Set(float64(s.InUse))
+		m.idle.WithLabelValues(name).Set(float64(s.Idle))
+
+		// the stats of a new pool, e.g. after ReconnectDB or
+		// InitNamedDB, start from zero again
+		last := m.last[name].stats
+		if m.last[name].db != sample.db {
+			last = sql.DBStats{}
+		}
+		m.waitCount.WithLabelValues(name).Add(float64(s.WaitCount - last.WaitCount))
+		m.waitDuration.WithLabelValues(name).Add((s.WaitDuration - last.WaitDuration).Seconds())
+		m.maxIdleClosed.WithLabelValues(name).Add(float64(s.MaxIdleClosed - last.MaxIdleClosed))
+		m.maxIdleTimeClosed.WithLabelValues(name).Add(float64(s.MaxIdleTimeClosed - last.MaxIdleTimeClosed))
+		m.maxLifetimeClosed.WithLabelValues(name).Add(float64(s.MaxLifetimeClosed - last.MaxLifetimeClosed))
+		m.last[name] = sample
+	}
+}
+
+// redisPoolTrace - count the open connections of the redis pools
+func redisPoolTrace() trace.PoolTrace {
+	return trace.PoolTrace{
+		ConnCreated: func(evt trace.PoolConnCreated) {
+			if evt.Err == nil {
+				redisConnsOpen.Add(1)
+			}
+		},
+		ConnClosed: func(trace.PoolConnClosed) {
+			redisConnsOpen.Add(-1)
+		},
+	}
+}
+
+// mongoPoolEvent - count the open and in-use connections of the mongo
+// pool
+func mongoPoolEvent(evt *event.PoolEvent) {
+	switch evt.Type {
+	case event.ConnectionCreated:
+		mongoConnsOpen.Add(1)
+	case event.ConnectionClosed:
+		mongoConnsOpen.Add(-1)
+	case event.GetSucceeded:
+		mongoConnsInUse.Add(1)
+	case event.ConnectionReturned:
+		mongoConnsInUse.Add(-1)
+	}
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/pilinux/gorest/config"
+)
+
+func TestRegisterDBMetrics(t *testing.T) {
+	cfg := config.RDBMS{}
+	cfg.Env.Driver = "sqlite3"
+	cfg.Access.DbName = filepath.Join(t.TempDir(), "metrics.db")
+
+	db, err := InitNamedDB(DefaultDBName, cfg)
+	if err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+	if err := db.Exec("SELECT 1").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := RegisterDBMetrics(reg); err != nil {
+		t.Fatalf("failed to register metrics: %v", err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	names := map[string]bool{}
+	var open float64
+	for _, family := range families {
+		names[family.GetName()] = true
+		if family.GetName() == "gorest_db_open_connections" {
+			for _, metric := range family.GetMetric() {
+				open += metric.GetGauge().GetValue()
+			}
+		}
+	}
+	for _, name := range []string{
+		"gorest_db_open_connections",
+		"gorest_db_wait_count_total",
+		"gorest_redis_open_connections",
+		"gorest_mongo_in_use_connections",
+	} {
+		if !names[name] {
+			t.Errorf("missing metric %s", name)
+		}
+	}
+
+	if open < 1 {
+		t.Errorf("expected at least 1 open connection, got %v", open)
+	}
+
+	if err := CloseDB(); err != nil {
+		t.Fatalf("failed to close db: %v", err)
+	}
+	dbMetricsMu.Lock()
+	stop := dbMetricsStop
+	dbMetricsMu.Unlock()
+	if stop != nil {
+		t.Error("CloseDB must stop reading the stats")
+	}
+
+	// a second registration with the same registry fails
+	if err := RegisterDBMetrics(reg); err == nil {
+		t.Error("expected an error for duplicate metrics")
+	}
+}
+
+func TestDBMetricsPoolSwap(t *testing.T) {
+	const name = "metrics_swap"
+	open := func(file string) *sql.DB {
+		cfg := config.RDBMS{}
+		cfg.Env.Driver = "sqlite3"
+		cfg.Access.DbName = filepath.Join(t.TempDir(), file)
+		db, err := InitNamedDB(name, cfg)
+		if err != nil {
+			t.Fatalf("failed to init db: %v", err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sqlDB
+	}
+	defer func() {
+		_ = CloseNamedDB(name)
+	}()
+
+	// each released connection is closed
+	first := open("first.db")
+	first.SetMaxIdleConns(0)
+	for i := 0; i < 3; i++ {
+		if _, err := first.Exec("SELECT 1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := newSQLMetrics()
+	m.scrape()
+	closed := counterValue(t, m.maxIdleClosed, name)
+	if closed < 3 {
+		t.Fatalf("expected at least 3 connections closed, got %v", closed)
+	}
+
+	// the new pool starts from zero, the counters must not decrease
+	second := open("second.db")
+	m.scrape()
+	if got := counterValue(t, m.maxIdleClosed, name); got != closed {
+		t.Errorf("expected %v after the swap, got %v", closed, got)
+	}
+
+	second.SetMaxIdleConns(0)
+	if _, err := second.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	m.scrape()
+	if got := counterValue(t, m.maxIdleClosed, name); got <= closed {
+		t.Errorf("expected more than %v after the closes of the new pool, got %v", closed, got)
+	}
+}
+
+// counterValue - value of the counter of the connection name
+func counterValue(t *testing.T, c *prometheus.CounterVec, name string) float64 {
+	var metric dto.Metric
+	if err := c.WithLabelValues(name).Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+
+	return metric.GetCounter().GetValue()
+}
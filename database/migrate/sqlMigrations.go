@@ -0,0 +1,113 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/pilinux/gorest/database"
+)
+
+// SQLMigration - applied SQL file stored in the version table of
+// RunSQLMigrations
+type SQLMigration struct {
+	ID        uint      `gorm:"primaryKey"`
+	Name      string    `gorm:"size:255;uniqueIndex"`
+	Checksum  string    `gorm:"size:64"`
+	AppliedAt time.Time `gorm:"autoCreateTime:false"`
+}
+
+// TableName - name of the version table of the SQL files
+func (SQLMigration) TableName() string {
+	return "sql_migrations"
+}
+
+// RunSQLMigrations - execute the .sql files of dir in lexical order and
+// record each file in the version table, for the schema objects that
+// AutoMigrate can not express: functions, triggers, views...
+//
+// The files already applied are skipped, repeated runs do not touch
+// the database. Each file is executed in a transaction with its
+// record, the run stops at the first failing file and the error
+// contains its name. A file modified after it was applied is reported
+// as an error, add a new file instead.
+//
+// A file may contain several statements, mysql requires
+// multiStatements=true in the DSN for that. mysql also commits the DDL
+// statements implicitly, a failing file may be partially applied.
+//
+// Example with embedded files:
+//
+//	//go:embed migrations/*.sql
+//	var migrations embed.FS
+//
+//	err := migrate.RunSQLMigrations(ctx, migrations, "migrations")
+func RunSQLMigrations(ctx context.Context, fsys fs.FS, dir string) error {
+	db := database.GetDB()
+	if db == nil {
+		return database.ErrDBNotInitialized
+	}
+	db = db.WithContext(ctx)
+
+	// sorted by file name
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("failed to read the sql migrations: %w", err)
+	}
+
+	if err := db.AutoMigrate(&SQLMigration{}); err != nil {
+		return fmt.Errorf("failed to create the version table: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.EqualFold(path.Ext(name), ".sql") {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("sql migration %s: %w", name, err)
+		}
+		sum := sha256.Sum256(content)
+		checksum := hex.EncodeToString(sum[:])
+
+		var applied SQLMigration
+		result := db.Where("name = ?", name).Limit(1).Find(&applied)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			if applied.Checksum != checksum {
+				return fmt.Errorf("sql migration %s: modified after it was applied", name)
+			}
+			continue
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(string(content)).Error; err != nil {
+				return err
+			}
+
+			return tx.Create(&SQLMigration{
+				Name:      name,
+				Checksum:  checksum,
+				AppliedAt: time.Now().UTC(),
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("sql migration %s: %w", name, err)
+		}
+		log.WithField("migration", name).Info("sql migration applied")
+	}
+
+	return nil
+}
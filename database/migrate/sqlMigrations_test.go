@@ -0,0 +1,66 @@
+package migrate_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pilinux/gorest/database"
+	"github.com/pilinux/gorest/database/migrate"
+)
+
+func TestRunSQLMigrations(t *testing.T) {
+	if _, err := database.InitTestDB(); err != nil {
+		t.Fatal(err)
+	}
+	defer database.CloseDB()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"sql/002_view.sql":   {Data: []byte("CREATE VIEW active_notes AS SELECT id FROM notes WHERE active = 1;")},
+		"sql/001_notes.sql":  {Data: []byte("CREATE TABLE notes (id INTEGER PRIMARY KEY, active INTEGER);\nINSERT INTO notes (active) VALUES (1);")},
+		"sql/README.md":      {Data: []byte("not a migration")},
+		"sql/003_broken.sql": {Data: []byte("CREATE TABLE;")},
+		"sql/004_later.sql":  {Data: []byte("CREATE TABLE later (id INTEGER);")},
+	}
+
+	err := migrate.RunSQLMigrations(ctx, fsys, "sql")
+	if err == nil || !strings.Contains(err.Error(), "003_broken.sql") {
+		t.Fatalf("expected the failing file in the error, got %v", err)
+	}
+
+	var applied []migrate.SQLMigration
+	if err := database.GetDB().Order("id").Find(&applied).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 || applied[0].Name != "001_notes.sql" || applied[1].Name != "002_view.sql" {
+		t.Fatalf("unexpected migrations: %+v", applied)
+	}
+	if database.GetDB().Migrator().HasTable("later") {
+		t.Error("expected the run to stop at the failing file")
+	}
+
+	// fixed file, the applied files are skipped
+	fsys["sql/003_broken.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE fixed (id INTEGER);")}
+	if err := migrate.RunSQLMigrations(ctx, fsys, "sql"); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	if err := database.GetDB().Table("active_notes").Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("expected 1 active note, got %d: %v", count, err)
+	}
+	if err := database.GetDB().Model(&migrate.SQLMigration{}).Count(&count).Error; err != nil || count != 4 {
+		t.Errorf("expected 4 migrations, got %d: %v", count, err)
+	}
+
+	// applied file modified
+	fsys["sql/001_notes.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE notes (id INTEGER);")}
+	if err := migrate.RunSQLMigrations(ctx, fsys, "sql"); err == nil || !strings.Contains(err.Error(), "001_notes.sql") {
+		t.Errorf("expected an error for the modified file, got %v", err)
+	}
+
+	if err := migrate.RunSQLMigrations(ctx, fsys, "missing"); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,200 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/pilinux/gorest/database"
+)
+
+// SchemaMigration - applied migration stored in the version table
+type SchemaMigration struct {
+	ID        uint      `gorm:"primaryKey"`
+	Name      string    `gorm:"size:255;uniqueIndex"`
+	Checksum  string    `gorm:"size:64"`
+	AppliedAt time.Time `gorm:"autoCreateTime:false"`
+}
+
+// TableName - name of the version table
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migration states reported by MigrationStatus
+const (
+	StateApplied = "applied" // migrated with the current schema of the model
+	StatePending = "pending" // never migrated
+	StateChanged = "changed" // the model changed since it was migrated
+)
+
+// MigrationState - state of the migration of a model
+type MigrationState struct {
+	Name      string
+	Checksum  string
+	State     string
+	AppliedAt *time.Time
+}
+
+// Migrate - migrate the models in the given order and record each
+// migration in the version table
+//
+// A model is migrated with AutoMigrate when it has not been migrated
+// yet, or when its schema (columns, types, constraints, indexes)
+// changed since the last migration. Repeated runs with unchanged
+// models do not touch the database.
+func Migrate(models ...interface{}) error {
+	db := database.GetDB()
+	if db == nil {
+		return database.ErrDBNotInitialized
+	}
+
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create the version table: %w", err)
+	}
+
+	for _, model := range models {
+		name, checksum, err := modelChecksum(db, model)
+		if err != nil {
+			return err
+		}
+
+		var applied SchemaMigration
+		result := db.Where("name = ?", name).Limit(1).Find(&applied)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 && applied.Checksum == checksum {
+			continue
+		}
+		if result.RowsAffected == 1 {
+			log.WithFields(log.Fields{
+				"migration": name,
+				"applied":   applied.Checksum,
+				"current":   checksum,
+			}).Warn("schema drift detected, migrating again")
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(model); err != nil {
+				return err
+			}
+
+			applied.Name = name
+			applied.Checksum = checksum
+			applied.AppliedAt = time.Now().UTC()
+			return tx.Save(&applied).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// MigrationStatus - list the state of the given models, followed by
+// the applied migrations of models which are not given
+func MigrationStatus(models ...interface{}) ([]MigrationState, error) {
+	db := database.GetDB()
+	if db == nil {
+		return nil, database.ErrDBNotInitialized
+	}
+
+	applied := map[string]SchemaMigration{}
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		var migrations []SchemaMigration
+		if err := db.Order("id").Find(&migrations).Error; err != nil {
+			return nil, err
+		}
+		for _, migration := range migrations {
+			applied[migration.Name] = migration
+		}
+	}
+
+	states := make([]MigrationState, 0, len(models)+len(applied))
+	for _, model := range models {
+		name, checksum, err := modelChecksum(db, model)
+		if err != nil {
+			return nil, err
+		}
+
+		state := MigrationState{Name: name, Checksum: checksum, State: StatePending}
+		if migration, ok := applied[name]; ok {
+			appliedAt := migration.AppliedAt
+			state.AppliedAt = &appliedAt
+			state.State = StateApplied
+			if migration.Checksum != checksum {
+				state.State = StateChanged
+			}
+			delete(applied, name)
+		}
+		states = append(states, state)
+	}
+
+	// applied migrations of models which were not given, sorted by name
+	rest := make([]string, 0, len(applied))
+	for name := range applied {
+		rest = append(rest, name)
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		migration := applied[name]
+		appliedAt := migration.AppliedAt
+		states = append(states, MigrationState{
+			Name:      name,
+			Checksum:  migration.Checksum,
+			State:     StateApplied,
+			AppliedAt: &appliedAt,
+		})
+	}
+
+	return states, nil
+}
+
+// modelChecksum - name of the table of the model and the SHA-256 of
+// its schema
+func modelChecksum(db *gorm.DB, model interface{}) (name, checksum string, err error) {
+	s, err := schema.Parse(model, &sync.Map{}, db.NamingStrategy)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse the model: %w", err)
+	}
+
+	var b strings.Builder
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "%s|%s|%d|%d|%d|%t|%t|%t|%t|%s\n",
+			field.DBName, field.DataType, field.Size, field.Precision, field.Scale,
+			field.PrimaryKey, field.AutoIncrement, field.NotNull, field.Unique, field.DefaultValue,
+		)
+	}
+
+	indexes := s.ParseIndexes()
+	indexNames := make([]string, 0, len(indexes))
+	for indexName := range indexes {
+		indexNames = append(indexNames, indexName)
+	}
+	sort.Strings(indexNames)
+	for _, indexName := range indexNames {
+		index := indexes[indexName]
+		fields := make([]string, 0, len(index.Fields))
+		for _, field := range index.Fields {
+			fields = append(fields, field.DBName)
+		}
+		fmt.Fprintf(&b, "index|%s|%s|%s\n", indexName, index.Class, strings.Join(fields, ","))
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+
+	return s.Table, hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,101 @@
+package migrate_test
+
+import (
+	"testing"
+
+	"github.com/pilinux/gorest/database"
+	"github.com/pilinux/gorest/database/migrate"
+)
+
+type book struct {
+	ID    uint
+	Title string
+}
+
+type author struct {
+	ID   uint
+	Name string
+}
+
+// bookV2 - book with a new column, same table
+type bookV2 struct {
+	ID    uint
+	Title string
+	ISBN  string `gorm:"size:13"`
+}
+
+func (bookV2) TableName() string {
+	return "books"
+}
+
+func TestMigrate(t *testing.T) {
+	if _, err := database.InitTestDB(); err != nil {
+		t.Fatal(err)
+	}
+	defer database.CloseDB()
+
+	states, err := migrate.MigrationStatus(&book{}, &author{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, state := range states {
+		if state.State != migrate.StatePending {
+			t.Errorf("%s: expected %s, got %s", state.Name, migrate.StatePending, state.State)
+		}
+	}
+
+	if err := migrate.Migrate(&book{}, &author{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var first []migrate.SchemaMigration
+	if err := database.GetDB().Order("id").Find(&first).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 || first[0].Name != "books" || first[1].Name != "authors" {
+		t.Fatalf("unexpected migrations: %+v", first)
+	}
+
+	// repeated run is a no-op
+	if err := migrate.Migrate(&book{}, &author{}); err != nil {
+		t.Fatal(err)
+	}
+	var second []migrate.SchemaMigration
+	if err := database.GetDB().Order("id").Find(&second).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 2 || !second[0].AppliedAt.Equal(first[0].AppliedAt) {
+		t.Fatalf("repeated run changed the version table: %+v", second)
+	}
+
+	// drift
+	states, err = migrate.MigrationStatus(&bookV2{}, &author{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if states[0].State != migrate.StateChanged || states[1].State != migrate.StateApplied {
+		t.Fatalf("unexpected states: %+v", states)
+	}
+
+	if err := migrate.Migrate(&bookV2{}); err != nil {
+		t.Fatal(err)
+	}
+	if !database.GetDB().Migrator().HasColumn(&bookV2{}, "isbn") {
+		t.Error("expected column isbn")
+	}
+
+	states, err = migrate.MigrationStatus(&bookV2{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 2 || states[0].State != migrate.StateApplied || states[1].Name != "authors" {
+		t.Fatalf("unexpected states: %+v", states)
+	}
+}
+
+func TestMigrateNotInitialized(t *testing.T) {
+	database.CloseDB()
+	if err := migrate.Migrate(&book{}); err != database.ErrDBNotInitialized {
+		t.Errorf("expected ErrDBNotInitialized, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/qiniu/qmgo"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// Aggregate - run pipeline on collection of the default database
+// (MONGO_DATABASE) and decode all result documents into results, a
+// pointer to a slice
+//
+// The cursor is closed before Aggregate returns, also when ctx is done
+// while the documents are read.
+//
+//	pipeline := mongo.Pipeline{
+//		{{Key: "$match", Value: bson.M{"status": "paid"}}},
+//		{{Key: "$group", Value: bson.M{"_id": "$customer", "total": bson.M{"$sum": "$amount"}}}},
+//	}
+//	var totals []struct {
+//		Customer string  `bson:"_id"`
+//		Total    float64 `bson:"total"`
+//	}
+//	err := database.Aggregate(ctx, "orders", pipeline, &totals)
+func Aggregate(ctx context.Context, collection string, pipeline mongo.Pipeline, results interface{}) error {
+	if v := reflect.ValueOf(results); v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return errors.New("mongo: results must be a pointer to a slice")
+	}
+
+	db := GetMongoDB()
+	if db == nil {
+		if mongoDeactivated(config.GetConfig()) {
+			return &NotActivatedError{Datastore: "mongo"}
+		}
+		return ErrMongoNotInitialized
+	}
+
+	return aggregate(ctx, db, collection, pipeline, results)
+}
+
+// aggregate - run pipeline on collection of db
+func aggregate(ctx context.Context, db *qmgo.Database, collection string, pipeline mongo.Pipeline, results interface{}) error {
+	coll, err := db.Collection(collection).CloneCollection()
+	if err != nil {
+		return fmt.Errorf("mongo: %w", err)
+	}
+	if pipeline == nil {
+		pipeline = mongo.Pipeline{}
+	}
+
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	// All closes the cursor with ctx, the server keeps it open when
+	// ctx is already done
+	defer cursor.Close(context.WithoutCancel(ctx))
+
+	return cursor.All(ctx, results)
+}
@@ -0,0 +1,25 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAggregateNotInitialized(t *testing.T) {
+	ctx := context.Background()
+
+	var results []bson.M
+	if err := Aggregate(ctx, "orders", nil, &results); !errors.Is(err, ErrMongoNotInitialized) {
+		t.Errorf("expected ErrMongoNotInitialized, got %v", err)
+	}
+	if err := Aggregate(ctx, "orders", nil, results); err == nil {
+		t.Error("expected an error for results which is not a pointer")
+	}
+	var result bson.M
+	if err := Aggregate(ctx, "orders", nil, &result); err == nil {
+		t.Error("expected an error for results which is not a slice")
+	}
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"errors"
+	"strconv"
+
+	opts "go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// applyMongoConcerns - set the write concern, read concern and read
+// preference of the config, the defaults of the driver are kept for
+// the empty values
+//
+// The options in the URI (e.g. w=majority) take precedence.
+//
+// - write concern: majority or the number of acknowledging nodes (0, 1, 2, ...)
+//
+// - read concern: local, available, majority, linearizable, snapshot
+//
+// - read preference: primary, primaryPreferred, secondary,
+// secondaryPreferred, nearest
+func applyMongoConcerns(opt *opts.ClientOptions, configureMongo config.MongoDB) error {
+	if w := configureMongo.Env.WriteConcern; w != "" {
+		if w == "majority" {
+			opt.SetWriteConcern(writeconcern.Majority())
+		} else {
+			n, err := strconv.Atoi(w)
+			if err != nil || n < 0 {
+				return errors.New("mongo: invalid write concern '" + w + "', expected majority or a number of nodes")
+			}
+			opt.SetWriteConcern(&writeconcern.WriteConcern{W: n})
+		}
+	}
+
+	switch level := configureMongo.Env.ReadConcern; level {
+	case "":
+	case "local", "available", "majority", "linearizable", "snapshot":
+		opt.SetReadConcern(&readconcern.ReadConcern{Level: level})
+	default:
+		return errors.New("mongo: invalid read concern '" + level + "', expected local, available, majority, linearizable or snapshot")
+	}
+
+	if pref := configureMongo.Env.ReadPreference; pref != "" {
+		mode, err := readpref.ModeFromString(pref)
+		if err != nil {
+			return errors.New("mongo: invalid read preference '" + pref + "', expected primary, primaryPreferred, secondary, secondaryPreferred or nearest")
+		}
+		readPref, err := readpref.New(mode)
+		if err != nil {
+			return errors.New("mongo: " + err.Error())
+		}
+		opt.SetReadPreference(readPref)
+	}
+
+	return nil
+}
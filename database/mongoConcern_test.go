@@ -0,0 +1,74 @@
+package database
+
+import (
+	"testing"
+
+	opts "go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+
+	"github.com/pilinux/gorest/config"
+)
+
+func TestApplyMongoConcerns(t *testing.T) {
+	configureMongo := config.MongoDB{}
+	configureMongo.Env.WriteConcern = "majority"
+	configureMongo.Env.ReadConcern = "majority"
+	configureMongo.Env.ReadPreference = "secondaryPreferred"
+
+	opt := opts.Client()
+	if err := applyMongoConcerns(opt, configureMongo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opt.WriteConcern == nil || opt.WriteConcern.W != "majority" {
+		t.Errorf("unexpected write concern %v", opt.WriteConcern)
+	}
+	if opt.ReadConcern == nil || opt.ReadConcern.Level != "majority" {
+		t.Errorf("unexpected read concern %v", opt.ReadConcern)
+	}
+	if opt.ReadPreference == nil || opt.ReadPreference.Mode() != readpref.SecondaryPreferredMode {
+		t.Errorf("unexpected read preference %v", opt.ReadPreference)
+	}
+
+	configureMongo.Env.WriteConcern = "2"
+	opt = opts.Client()
+	if err := applyMongoConcerns(opt, configureMongo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opt.WriteConcern == nil || opt.WriteConcern.W != 2 {
+		t.Errorf("unexpected write concern %v", opt.WriteConcern)
+	}
+}
+
+func TestApplyMongoConcernsDefaults(t *testing.T) {
+	opt := opts.Client()
+	if err := applyMongoConcerns(opt, config.MongoDB{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opt.WriteConcern != nil || opt.ReadConcern != nil || opt.ReadPreference != nil {
+		t.Error("the defaults of the driver must be kept")
+	}
+}
+
+func TestApplyMongoConcernsInvalid(t *testing.T) {
+	testCases := []struct {
+		writeConcern   string
+		readConcern    string
+		readPreference string
+	}{
+		{writeConcern: "majorty"},
+		{writeConcern: "-1"},
+		{readConcern: "strong"},
+		{readPreference: "secondaryPrefered"},
+	}
+
+	for _, tc := range testCases {
+		configureMongo := config.MongoDB{}
+		configureMongo.Env.WriteConcern = tc.writeConcern
+		configureMongo.Env.ReadConcern = tc.readConcern
+		configureMongo.Env.ReadPreference = tc.readPreference
+
+		if err := applyMongoConcerns(opts.Client(), configureMongo); err == nil {
+			t.Errorf("expected an error for %+v", tc)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// MongoMaxDocumentSize - maximum size of a BSON document accepted by
+// the server (16 MiB)
+const MongoMaxDocumentSize = 16 * 1024 * 1024
+
+// MongoDocSizeLimit - size limit of ValidateDocSize in bytes, lower it
+// to reject large documents before they reach the server; a value
+// above MongoMaxDocumentSize is rejected by the server anyway
+var MongoDocSizeLimit = MongoMaxDocumentSize
+
+// ErrMongoDocTooLarge - the BSON document exceeds MongoDocSizeLimit
+var ErrMongoDocTooLarge = errors.New("mongo: document is too large")
+
+// ValidateDocSize - marshal doc to BSON and verify that it does not
+// exceed MongoDocSizeLimit
+//
+// The returned error wraps ErrMongoDocTooLarge and reports the actual
+// size, instead of the error of the driver deep in the write path.
+//
+//	if err := database.ValidateDocSize(post); errors.Is(err, database.ErrMongoDocTooLarge) {
+//		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+//		return
+//	}
+func ValidateDocSize(doc interface{}) error {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("mongo: %w", err)
+	}
+
+	if size := len(data); size > MongoDocSizeLimit {
+		return fmt.Errorf("%w: %d bytes, limit %d bytes", ErrMongoDocTooLarge, size, MongoDocSizeLimit)
+	}
+
+	return nil
+}
+
+// InsertDocument - insert doc into collection of the default database
+// (MONGO_DATABASE) after ValidateDocSize, and return the ID of the new
+// document
+//
+// The document is marshaled twice, by ValidateDocSize and by the
+// driver.
+func InsertDocument(ctx context.Context, collection string, doc interface{}) (interface{}, error) {
+	if err := ValidateDocSize(doc); err != nil {
+		return nil, err
+	}
+
+	db := GetMongoDB()
+	if db == nil {
+		if mongoDeactivated(config.GetConfig()) {
+			return nil, &NotActivatedError{Datastore: "mongo"}
+		}
+		return nil, ErrMongoNotInitialized
+	}
+
+	result, err := db.Collection(collection).InsertOne(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.InsertedID, nil
+}
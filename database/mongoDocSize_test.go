@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestValidateDocSize(t *testing.T) {
+	if err := ValidateDocSize(bson.M{"name": "gorest"}); err != nil {
+		t.Errorf("expected a small document to be valid, got %v", err)
+	}
+
+	large := bson.M{"data": make([]byte, MongoMaxDocumentSize)}
+	err := ValidateDocSize(large)
+	if !errors.Is(err, ErrMongoDocTooLarge) {
+		t.Fatalf("expected ErrMongoDocTooLarge, got %v", err)
+	}
+	// document length, element type, key, binary length and subtype
+	size := MongoMaxDocumentSize + 4 + 1 + len("data") + 1 + 4 + 1 + 1
+	if !strings.Contains(err.Error(), strconv.Itoa(size)+" bytes") {
+		t.Errorf("expected the size %d in the error, got %v", size, err)
+	}
+
+	if err := ValidateDocSize(42); err == nil || errors.Is(err, ErrMongoDocTooLarge) {
+		t.Errorf("expected the marshal error, got %v", err)
+	}
+
+	defer func(limit int) {
+		MongoDocSizeLimit = limit
+	}(MongoDocSizeLimit)
+	MongoDocSizeLimit = 1024
+	if err := ValidateDocSize(bson.M{"data": strings.Repeat("a", 1024)}); !errors.Is(err, ErrMongoDocTooLarge) {
+		t.Errorf("expected ErrMongoDocTooLarge with a lower limit, got %v", err)
+	}
+}
+
+func TestInsertDocument(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := InsertDocument(ctx, "posts", bson.M{"name": "gorest"}); !errors.Is(err, ErrMongoNotInitialized) {
+		t.Errorf("expected ErrMongoNotInitialized, got %v", err)
+	}
+	large := bson.M{"data": make([]byte, MongoMaxDocumentSize)}
+	if _, err := InsertDocument(ctx, "posts", large); !errors.Is(err, ErrMongoDocTooLarge) {
+		t.Errorf("expected ErrMongoDocTooLarge, got %v", err)
+	}
+}
@@ -2,9 +2,15 @@ package database
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/qiniu/qmgo"
 	"github.com/qiniu/qmgo/options"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // MongoCreateIndex - create one index for a mongo collection
@@ -62,3 +68,117 @@ func MongoDropAllIndexes(dbName, collectionName string) error {
 	// drop all indexes
 	return collection.DropAllIndexes(ctx)
 }
+
+// registered indexes of RegisterMongoIndexes, by collection
+var (
+	mongoIndexesMu sync.Mutex
+	mongoIndexes   = make(map[string][]mongo.IndexModel)
+)
+
+// RegisterMongoIndexes - declare the indexes of a collection of the
+// default database (MONGO_DATABASE), they are created by InitAll or
+// EnsureRegisteredMongoIndexes
+//
+// Register the indexes before the initialization, e.g. in an init
+// function of the package of the model:
+//
+//	func init() {
+//		database.RegisterMongoIndexes("users", mongo.IndexModel{
+//			Keys:    bson.D{{Key: "email", Value: 1}},
+//			Options: options.Index().SetUnique(true),
+//		})
+//	}
+func RegisterMongoIndexes(collection string, models ...mongo.IndexModel) {
+	mongoIndexesMu.Lock()
+	defer mongoIndexesMu.Unlock()
+
+	mongoIndexes[collection] = append(mongoIndexes[collection], models...)
+}
+
+// EnsureRegisteredMongoIndexes - create the indexes declared with
+// RegisterMongoIndexes in the default database (MONGO_DATABASE)
+func EnsureRegisteredMongoIndexes(ctx context.Context) error {
+	db := GetMongoDB()
+	if db == nil {
+		return ErrMongoNotInitialized
+	}
+
+	return ensureRegisteredMongoIndexes(ctx, db)
+}
+
+// ensureRegisteredMongoIndexes - create the registered indexes in db
+func ensureRegisteredMongoIndexes(ctx context.Context, db *qmgo.Database) error {
+	mongoIndexesMu.Lock()
+	registered := make(map[string][]mongo.IndexModel, len(mongoIndexes))
+	for collection, models := range mongoIndexes {
+		registered[collection] = models
+	}
+	mongoIndexesMu.Unlock()
+
+	for collection, models := range registered {
+		if err := ensureMongoIndexes(ctx, db, collection, models); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EnsureMongoIndexes - create the indexes of a collection of the
+// default database (MONGO_DATABASE) unless they already exist
+//
+// Existing indexes with the same name and keys are left untouched, an
+// index with the same name but other keys or options fails with an
+// IndexOptionsConflict or IndexKeySpecsConflict error. The created and
+// the already existing indexes are logged.
+func EnsureMongoIndexes(ctx context.Context, collection string, models []mongo.IndexModel) error {
+	db := GetMongoDB()
+	if db == nil {
+		return ErrMongoNotInitialized
+	}
+
+	return ensureMongoIndexes(ctx, db, collection, models)
+}
+
+// ensureMongoIndexes - create the indexes of collection in db
+func ensureMongoIndexes(ctx context.Context, db *qmgo.Database, collection string, models []mongo.IndexModel) error {
+	if len(models) == 0 {
+		return nil
+	}
+
+	coll, err := db.Collection(collection).CloneCollection()
+	if err != nil {
+		return fmt.Errorf("mongo: %w", err)
+	}
+
+	specs, err := coll.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return fmt.Errorf("mongo: failed to list the indexes of %s: %w", collection, err)
+	}
+	existing := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		existing[spec.Name] = true
+	}
+
+	// createIndexes is idempotent, it returns the names of all indexes
+	names, err := coll.Indexes().CreateMany(ctx, models)
+	if err != nil {
+		return fmt.Errorf("mongo: failed to create the indexes of %s: %w", collection, err)
+	}
+
+	var created, kept []string
+	for _, name := range names {
+		if existing[name] {
+			kept = append(kept, name)
+		} else {
+			created = append(created, name)
+		}
+	}
+	log.WithFields(log.Fields{
+		"collection": collection,
+		"created":    strings.Join(created, ","),
+		"existing":   strings.Join(kept, ","),
+	}).Info("mongo: indexes ensured")
+
+	return nil
+}
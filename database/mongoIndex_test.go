@@ -0,0 +1,30 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestRegisterMongoIndexes(t *testing.T) {
+	defer func() {
+		mongoIndexes = make(map[string][]mongo.IndexModel)
+	}()
+
+	RegisterMongoIndexes("users", mongo.IndexModel{Keys: bson.D{{Key: "email", Value: 1}}})
+	RegisterMongoIndexes("users", mongo.IndexModel{Keys: bson.D{{Key: "name", Value: 1}}})
+	RegisterMongoIndexes("posts", mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}}})
+	if len(mongoIndexes["users"]) != 2 || len(mongoIndexes["posts"]) != 1 {
+		t.Errorf("unexpected registry %v", mongoIndexes)
+	}
+
+	if err := EnsureRegisteredMongoIndexes(context.Background()); !errors.Is(err, ErrMongoNotInitialized) {
+		t.Errorf("expected ErrMongoNotInitialized, got %v", err)
+	}
+	if err := EnsureMongoIndexes(context.Background(), "users", mongoIndexes["users"]); !errors.Is(err, ErrMongoNotInitialized) {
+		t.Errorf("expected ErrMongoNotInitialized, got %v", err)
+	}
+}
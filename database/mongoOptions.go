@@ -0,0 +1,46 @@
+package database
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	opts "go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// applyMongoTimeouts - set the configured timeouts of the client, the
+// defaults of the driver and the values of the URI are kept for the
+// timeouts which are not set
+func applyMongoTimeouts(opt *opts.ClientOptions, configureMongo config.MongoDB) {
+	if configureMongo.Env.ConnectTimeout > 0 {
+		opt.SetConnectTimeout(configureMongo.Env.ConnectTimeout)
+	}
+	if configureMongo.Env.ServerSelectionTimeout > 0 {
+		opt.SetServerSelectionTimeout(configureMongo.Env.ServerSelectionTimeout)
+	}
+	if configureMongo.Env.SocketTimeout > 0 {
+		opt.SetSocketTimeout(configureMongo.Env.SocketTimeout)
+	}
+}
+
+// mongoCompressors - wire compressors supported by the driver
+var mongoCompressors = []string{"snappy", "zlib", "zstd"}
+
+// applyMongoCompressors - enable the configured wire compressors, the
+// server picks the first one it supports
+func applyMongoCompressors(opt *opts.ClientOptions, configureMongo config.MongoDB) error {
+	if len(configureMongo.Env.Compressors) == 0 {
+		return nil
+	}
+
+	for _, compressor := range configureMongo.Env.Compressors {
+		if !slices.Contains(mongoCompressors, compressor) {
+			return errors.New("mongo: unsupported compressor '" + compressor + "', supported compressors: " + strings.Join(mongoCompressors, ", "))
+		}
+	}
+	opt.SetCompressors(configureMongo.Env.Compressors)
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	opts "go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/pilinux/gorest/config"
+)
+
+func TestApplyMongoTimeouts(t *testing.T) {
+	configureMongo := config.MongoDB{}
+	configureMongo.Env.ConnectTimeout = time.Second
+	configureMongo.Env.ServerSelectionTimeout = 2 * time.Second
+	configureMongo.Env.SocketTimeout = 3 * time.Second
+
+	opt := opts.Client()
+	applyMongoTimeouts(opt, configureMongo)
+	if opt.ConnectTimeout == nil || *opt.ConnectTimeout != time.Second {
+		t.Errorf("unexpected connect timeout %v", opt.ConnectTimeout)
+	}
+	if opt.ServerSelectionTimeout == nil || *opt.ServerSelectionTimeout != 2*time.Second {
+		t.Errorf("unexpected server selection timeout %v", opt.ServerSelectionTimeout)
+	}
+	if opt.SocketTimeout == nil || *opt.SocketTimeout != 3*time.Second {
+		t.Errorf("unexpected socket timeout %v", opt.SocketTimeout)
+	}
+
+	// the defaults of the driver are kept
+	opt = opts.Client()
+	applyMongoTimeouts(opt, config.MongoDB{})
+	if opt.ConnectTimeout != nil || opt.ServerSelectionTimeout != nil || opt.SocketTimeout != nil {
+		t.Error("expected no timeout to be set")
+	}
+}
+
+func TestInitMongoServerSelectionTimeout(t *testing.T) {
+	// nothing listens on the port once the listener is closed
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+
+	configureMongo := config.MongoDB{}
+	configureMongo.Env.URI = "mongodb://" + addr
+	configureMongo.Env.ConnTTL = 30
+	configureMongo.Env.ServerSelectionTimeout = 200 * time.Millisecond
+
+	start := time.Now()
+	if _, err := initMongo(configureMongo); err == nil {
+		t.Fatal("expected error for an unreachable server")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected to fail fast, took %v", elapsed)
+	}
+}
+
+func TestApplyMongoCompressors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		compressors []string
+		expectErr   bool
+	}{
+		{name: "disabled"},
+		{name: "zstd and snappy", compressors: []string{"zstd", "snappy"}},
+		{name: "zlib", compressors: []string{"zlib"}},
+		{name: "unsupported", compressors: []string{"zstd", "lz4"}, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configureMongo := config.MongoDB{}
+			configureMongo.Env.Compressors = tc.compressors
+
+			opt := opts.Client()
+			err := applyMongoCompressors(opt, configureMongo)
+			if tc.expectErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(opt.Compressors, tc.compressors) {
+				t.Errorf("expected compressors %v, got %v", tc.compressors, opt.Compressors)
+			}
+		})
+	}
+}
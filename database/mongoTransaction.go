@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/qiniu/qmgo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// ErrMongoNotInitialized - mongo has not been initialized
+var ErrMongoNotInitialized = errors.New("mongo is not initialized")
+
+// ErrMongoTransactionNotSupported - the deployment is a standalone
+// server, transactions require a replica set or a sharded cluster
+var ErrMongoTransactionNotSupported = errors.New("mongo: transactions require a replica set or a sharded cluster")
+
+// WithMongoTransaction - run fn in a multi-document transaction
+//
+// fn must pass sessCtx to all operations of the transaction. The
+// transaction is committed when fn returns nil and aborted otherwise.
+// The driver runs fn again on transient transaction errors, e.g. a
+// write conflict or a primary election, and retries the commit on
+// unknown commit results, for up to 120 seconds. fn must therefore be
+// idempotent.
+//
+//	err := database.WithMongoTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+//		if _, err := accounts.InsertOne(sessCtx, from); err != nil {
+//			return err
+//		}
+//		_, err := accounts.InsertOne(sessCtx, to)
+//		return err
+//	})
+func WithMongoTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error {
+	client := GetMongo()
+	if client == nil {
+		if mongoDeactivated(config.GetConfig()) {
+			return &NotActivatedError{Datastore: "mongo"}
+		}
+		return ErrMongoNotInitialized
+	}
+
+	if err := checkMongoTransactionSupport(ctx, client); err != nil {
+		return err
+	}
+
+	session, err := client.Session()
+	if err != nil {
+		return fmt.Errorf("mongo: failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.StartTransaction(ctx, func(sessCtx context.Context) (interface{}, error) {
+		return nil, fn(sessCtx.(mongo.SessionContext))
+	})
+
+	return err
+}
+
+// checkMongoTransactionSupport - return ErrMongoTransactionNotSupported
+// when the server is neither a replica set member nor a mongos
+func checkMongoTransactionSupport(ctx context.Context, client *qmgo.Client) error {
+	replicated, err := isMongoReplicated(ctx, client.Database("admin"))
+	if err != nil {
+		return err
+	}
+	if !replicated {
+		return ErrMongoTransactionNotSupported
+	}
+
+	return nil
+}
+
+// isMongoReplicated - whether the server is a replica set member or a
+// mongos, admin is the admin database
+func isMongoReplicated(ctx context.Context, admin *qmgo.Database) (bool, error) {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	// hello is only available from MongoDB 4.4.2, isMaster is supported
+	// by all servers with transactions and change streams
+	err := admin.RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return false, fmt.Errorf("mongo: %w", err)
+	}
+
+	return hello.SetName != "" || hello.Msg == "isdbgrid", nil
+}
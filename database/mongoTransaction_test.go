@@ -0,0 +1,23 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestWithMongoTransactionNotInitialized(t *testing.T) {
+	called := false
+	err := WithMongoTransaction(context.Background(), func(mongo.SessionContext) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrMongoNotInitialized) {
+		t.Errorf("expected ErrMongoNotInitialized, got %v", err)
+	}
+	if called {
+		t.Error("expected fn not to be called")
+	}
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/qiniu/qmgo"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// ErrMongoChangeStreamNotSupported - the deployment is a standalone
+// server, change streams require a replica set or a sharded cluster
+var ErrMongoChangeStreamNotSupported = errors.New("mongo: change streams require a replica set or a sharded cluster")
+
+// change stream reopen delays after a resumable error
+const (
+	watchRetryDelay    = 100 * time.Millisecond
+	watchRetryMaxDelay = 5 * time.Second
+)
+
+// WatchCollection - subscribe to the changes of collection in the
+// default database (MONGO_DATABASE)
+//
+// The change events, filtered by pipeline, are sent to the returned
+// channel until ctx is cancelled. When the change stream fails with a
+// resumable error, e.g. a primary election or a network error, it is
+// reopened after the last received event, so that no event is lost
+// or sent twice. The channel is closed when ctx is done or after an
+// error which cannot be resumed, e.g. when the collection is dropped.
+//
+//	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"operationType": "insert"}}}}
+//	events, err := database.WatchCollection(ctx, "orders", pipeline)
+//	if err != nil {
+//		return err
+//	}
+//	for event := range events {
+//		order := event["fullDocument"].(bson.M)
+//		...
+//	}
+func WatchCollection(ctx context.Context, collection string, pipeline mongo.Pipeline) (<-chan bson.M, error) {
+	client := GetMongo()
+	if client == nil {
+		if mongoDeactivated(config.GetConfig()) {
+			return nil, &NotActivatedError{Datastore: "mongo"}
+		}
+		return nil, ErrMongoNotInitialized
+	}
+
+	db := GetMongoDB()
+	if db == nil {
+		return nil, ErrMongoNotInitialized
+	}
+
+	return watchCollection(ctx, client, db, collection, pipeline)
+}
+
+// watchCollection - subscribe to the changes of collection in db
+func watchCollection(ctx context.Context, client *qmgo.Client, db *qmgo.Database, collection string, pipeline mongo.Pipeline) (<-chan bson.M, error) {
+	replicated, err := isMongoReplicated(ctx, client.Database("admin"))
+	if err != nil {
+		return nil, err
+	}
+	if !replicated {
+		return nil, ErrMongoChangeStreamNotSupported
+	}
+
+	coll, err := db.Collection(collection).CloneCollection()
+	if err != nil {
+		return nil, fmt.Errorf("mongo: %w", err)
+	}
+	if pipeline == nil {
+		pipeline = mongo.Pipeline{}
+	}
+	stream, err := coll.Watch(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("mongo: %w", err)
+	}
+
+	events := make(chan bson.M)
+	go watchChangeStream(ctx, coll, pipeline, stream, events)
+
+	return events, nil
+}
+
+// watchChangeStream - send the events of stream to events, reopen the
+// stream after a resumable error and close events once done
+func watchChangeStream(ctx context.Context, coll *mongo.Collection, pipeline mongo.Pipeline, stream *mongo.ChangeStream, events chan<- bson.M) {
+	defer close(events)
+
+	delay := watchRetryDelay
+	for {
+		for stream.Next(ctx) {
+			delay = watchRetryDelay
+
+			var event bson.M
+			if err := stream.Decode(&event); err != nil {
+				log.WithError(err).WithField("collection", coll.Name()).Error("mongo: failed to decode the change event")
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				_ = stream.Close(context.Background())
+				return
+			}
+		}
+
+		err := stream.Err()
+		// the token of the last event sent
+		resumeToken := stream.ResumeToken()
+		_ = stream.Close(context.Background())
+		if ctx.Err() != nil {
+			return
+		}
+		if !isResumableChangeStreamError(err) {
+			if err != nil {
+				log.WithError(err).WithField("collection", coll.Name()).Error("mongo: change stream closed")
+			}
+			return
+		}
+
+		// reopened until it succeeds or ctx is done
+		for {
+			log.WithError(err).WithFields(log.Fields{
+				"collection": coll.Name(),
+				"retryIn":    delay.String(),
+			}).Warn("mongo: change stream interrupted, resuming")
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			delay = min(delay*2, watchRetryMaxDelay)
+
+			opts := options.ChangeStream()
+			if resumeToken != nil {
+				opts.SetResumeAfter(resumeToken)
+			}
+			stream, err = coll.Watch(ctx, pipeline, opts)
+			if err == nil {
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if !isResumableChangeStreamError(err) {
+				log.WithError(err).WithField("collection", coll.Name()).Error("mongo: failed to resume the change stream")
+				return
+			}
+		}
+	}
+}
+
+// isResumableChangeStreamError - whether the change stream can be
+// reopened after err
+func isResumableChangeStreamError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorLabel("ResumableChangeStreamError")
+}
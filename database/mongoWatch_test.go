@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestWatchCollectionNotInitialized(t *testing.T) {
+	if _, err := WatchCollection(context.Background(), "orders", nil); !errors.Is(err, ErrMongoNotInitialized) {
+		t.Errorf("expected ErrMongoNotInitialized, got %v", err)
+	}
+}
+
+func TestIsResumableChangeStreamError(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "other", err: errors.New("decode"), want: false},
+		{name: "network", err: mongo.CommandError{Labels: []string{"NetworkError"}}, want: true},
+		{name: "resumable", err: mongo.CommandError{Code: 10107, Labels: []string{"ResumableChangeStreamError"}}, want: true},
+		{name: "not resumable", err: mongo.CommandError{Code: 280, Name: "ChangeStreamFatalError"}, want: false},
+		{name: "context", err: context.Canceled, want: false},
+	}
+	for _, tc := range testCases {
+		if got := isResumableChangeStreamError(tc.err); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// DefaultDBName - name of the connection initialized by InitDB
+const DefaultDBName string = "default"
+
+// dbRegistry - named connections to relational databases
+var (
+	dbRegistryMu sync.RWMutex
+	dbRegistry   = make(map[string]*gorm.DB)
+)
+
+// InitNamedDB - initialize a connection to a relational database and
+// register it under the given name
+//
+// An application can talk to several databases at the same time, e.g.
+// one for authentication and one for the content. Each connection has
+// its own pool. Initializing DefaultDBName also replaces the connection
+// returned by GetDB.
+func InitNamedDB(name string, cfg config.RDBMS) (*gorm.DB, error) {
+	if name == "" {
+		return nil, errors.New("connection name is required")
+	}
+
+	db, err := openDB(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dbRegistryMu.Lock()
+	previous := dbRegistry[name]
+	dbRegistry[name] = db
+	if name == DefaultDBName {
+		dbClient = db
+	}
+	dbRegistryMu.Unlock()
+
+	// release the pool of the replaced connection
+	if previous != nil {
+		_ = closePool(previous)
+	}
+
+	return db, nil
+}
+
+// GetNamedDB - get the connection registered under the given name,
+// nil if no such connection exists
+func GetNamedDB(name string) *gorm.DB {
+	dbRegistryMu.RLock()
+	defer dbRegistryMu.RUnlock()
+
+	return dbRegistry[name]
+}
+
+// CloseNamedDB - close the connection pool registered under the given
+// name and remove it from the registry
+//
+// It is safe to call CloseNamedDB for an unknown name.
+func CloseNamedDB(name string) error {
+	dbRegistryMu.Lock()
+	db := dbRegistry[name]
+	delete(dbRegistry, name)
+	if name == DefaultDBName {
+		dbClient = nil
+	}
+	dbRegistryMu.Unlock()
+
+	if db == nil {
+		return nil
+	}
+
+	if err := closePool(db); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
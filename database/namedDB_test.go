@@ -0,0 +1,87 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pilinux/gorest/config"
+)
+
+func TestNamedDB(t *testing.T) {
+	dir := t.TempDir()
+
+	authCfg := config.RDBMS{}
+	authCfg.Env.Driver = "sqlite3"
+	authCfg.Access.DbName = filepath.Join(dir, "auth.db")
+
+	contentCfg := config.RDBMS{}
+	contentCfg.Env.Driver = "sqlite3"
+	contentCfg.Access.DbName = filepath.Join(dir, "content.db")
+
+	authDB, err := InitNamedDB("auth", authCfg)
+	if err != nil {
+		t.Fatalf("failed to init auth db: %v", err)
+	}
+	contentDB, err := InitNamedDB("content", contentCfg)
+	if err != nil {
+		t.Fatalf("failed to init content db: %v", err)
+	}
+	defer func() {
+		_ = CloseNamedDB("auth")
+		_ = CloseNamedDB("content")
+	}()
+
+	if GetNamedDB("auth") != authDB {
+		t.Error("GetNamedDB returned the wrong auth connection")
+	}
+	if GetNamedDB("content") != contentDB {
+		t.Error("GetNamedDB returned the wrong content connection")
+	}
+	if GetNamedDB("unknown") != nil {
+		t.Error("expected nil for an unknown connection")
+	}
+	if GetDB() != nil {
+		t.Error("named connections must not replace the default connection")
+	}
+
+	if err := authDB.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY)").Error; err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if contentDB.Migrator().HasTable("users") {
+		t.Error("connections must not share the same database")
+	}
+
+	if err := CloseNamedDB("auth"); err != nil {
+		t.Errorf("failed to close auth db: %v", err)
+	}
+	if GetNamedDB("auth") != nil {
+		t.Error("expected nil after closing the connection")
+	}
+}
+
+func TestNamedDBDefault(t *testing.T) {
+	cfg := config.RDBMS{}
+	cfg.Env.Driver = "sqlite3"
+	cfg.Access.DbName = filepath.Join(t.TempDir(), "default.db")
+
+	db, err := InitNamedDB(DefaultDBName, cfg)
+	if err != nil {
+		t.Fatalf("failed to init default db: %v", err)
+	}
+	if GetDB() != db {
+		t.Error("GetDB must return the default named connection")
+	}
+
+	if err := CloseDB(); err != nil {
+		t.Errorf("failed to close default db: %v", err)
+	}
+	if GetNamedDB(DefaultDBName) != nil {
+		t.Error("CloseDB must remove the default named connection")
+	}
+}
+
+func TestNamedDBEmptyName(t *testing.T) {
+	if _, err := InitNamedDB("", config.RDBMS{}); err == nil {
+		t.Error("expected an error for an empty connection name")
+	}
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mediocregopher/radix/v4"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// DefaultRedisName - name of the client initialized by InitRedis
+const DefaultRedisName string = "default"
+
+// redisRegistry - named redis clients, the default client is kept in
+// redisClient
+var (
+	redisRegistryMu sync.RWMutex
+	redisRegistry   = make(map[string]*radix.Client)
+)
+
+// InitNamedRedis - initialize a redis client and register it under the
+// given name
+//
+// An application can use several redis servers at the same time, e.g.
+// one for the sessions and one for the cache. Each client has its own
+// pool. Initializing DefaultRedisName replaces the client returned by
+// GetRedis, the health check of REDIS_HEALTH_CHECK_INTERVAL only
+// monitors this client. Unlike InitRedis, InitNamedRedis does not
+// panic when the connection fails.
+func InitNamedRedis(name string, cfg config.REDIS) (*radix.Client, error) {
+	if name == "" {
+		return nil, errors.New("redis: client name is required")
+	}
+
+	dialer, err := validatedRedisDialer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	rClient, err := openRedis(context.Background(), cfg, dialer)
+	if err != nil {
+		return nil, fmt.Errorf("redis: %s: %w", name, err)
+	}
+	client := &rClient
+
+	var previous *radix.Client
+	if name == DefaultRedisName {
+		RedisConnTTL = cfg.Conn.ConnTTL
+		redisPoolSize.Store(int64(cfg.Conn.PoolSize))
+		stopRedisHealthCheck()
+		previous = currentRedis()
+		setRedis(client)
+		startRedisHealthCheck(cfg)
+	} else {
+		redisRegistryMu.Lock()
+		previous = redisRegistry[name]
+		redisRegistry[name] = client
+		redisRegistryMu.Unlock()
+	}
+
+	// release the pool of the replaced client
+	if previous != nil {
+		_ = (*previous).Close()
+	}
+
+	return client, nil
+}
+
+// GetNamedRedis - get the redis client registered under the given
+// name, nil if no such client exists
+//
+// GetNamedRedis(DefaultRedisName) is the same as GetRedis.
+func GetNamedRedis(name string) *radix.Client {
+	if name == DefaultRedisName {
+		return GetRedis()
+	}
+
+	redisRegistryMu.RLock()
+	defer redisRegistryMu.RUnlock()
+
+	return redisRegistry[name]
+}
+
+// CloseNamedRedis - close the pool of the redis client registered
+// under the given name and remove it from the registry
+//
+// It is safe to call CloseNamedRedis for an unknown name.
+func CloseNamedRedis(name string) error {
+	if name == DefaultRedisName {
+		return CloseRedis()
+	}
+
+	redisRegistryMu.Lock()
+	client := redisRegistry[name]
+	delete(redisRegistry, name)
+	redisRegistryMu.Unlock()
+
+	if client == nil {
+		return nil
+	}
+	if err := (*client).Close(); err != nil {
+		return fmt.Errorf("redis: %s: failed to close connection pool: %w", name, err)
+	}
+
+	return nil
+}
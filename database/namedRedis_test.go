@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mediocregopher/radix/v4"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// miniredisConfig - config of a client connecting to s
+func miniredisConfig(s *miniredis.Miniredis) config.REDIS {
+	cfg := config.REDIS{}
+	cfg.Env.Host = s.Host()
+	cfg.Env.Port = s.Port()
+	cfg.Conn.PoolSize = 2
+	cfg.Conn.ConnTTL = 5
+
+	return cfg
+}
+
+func TestNamedRedis(t *testing.T) {
+	sessions := miniredis.RunT(t)
+	cache := miniredis.RunT(t)
+	ctx := context.Background()
+
+	sessionsClient, err := InitNamedRedis("sessions", miniredisConfig(sessions))
+	if err != nil {
+		t.Fatalf("failed to init sessions client: %v", err)
+	}
+	cacheClient, err := InitNamedRedis("cache", miniredisConfig(cache))
+	if err != nil {
+		t.Fatalf("failed to init cache client: %v", err)
+	}
+	defer func() {
+		_ = CloseNamedRedis("sessions")
+		_ = CloseNamedRedis("cache")
+	}()
+
+	if GetNamedRedis("sessions") != sessionsClient || GetNamedRedis("cache") != cacheClient {
+		t.Error("GetNamedRedis returned the wrong client")
+	}
+	if GetNamedRedis("unknown") != nil {
+		t.Error("expected nil for an unknown client")
+	}
+	if currentRedis() != nil {
+		t.Error("named clients must not replace the default client")
+	}
+
+	if err := (*sessionsClient).Do(ctx, radix.Cmd(nil, "SET", "sid", "1")); err != nil {
+		t.Fatal(err)
+	}
+	if !sessions.Exists("sid") || cache.Exists("sid") {
+		t.Error("expected the key in the sessions server only")
+	}
+
+	// replacing a client closes the previous one
+	replaced, err := InitNamedRedis("cache", miniredisConfig(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (*cacheClient).Do(ctx, radix.Cmd(nil, "PING")); err == nil {
+		t.Error("expected the replaced client to be closed")
+	}
+	if GetNamedRedis("cache") != replaced {
+		t.Error("expected the new cache client")
+	}
+
+	if err := CloseNamedRedis("cache"); err != nil {
+		t.Fatal(err)
+	}
+	if GetNamedRedis("cache") != nil {
+		t.Error("expected the closed client to be removed")
+	}
+	if err := CloseNamedRedis("cache"); err != nil {
+		t.Errorf("closing an unknown client must not fail: %v", err)
+	}
+
+	if _, err := InitNamedRedis("", miniredisConfig(cache)); err == nil {
+		t.Error("expected an error for an empty name")
+	}
+}
+
+func TestNamedRedisDefault(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	client, err := InitNamedRedis(DefaultRedisName, miniredisConfig(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseRedis()
+
+	if GetRedis() != client || GetNamedRedis(DefaultRedisName) != client {
+		t.Error("expected the default client to be returned by GetRedis")
+	}
+	if err := CloseNamedRedis(DefaultRedisName); err != nil {
+		t.Fatal(err)
+	}
+	if currentRedis() != nil {
+		t.Error("expected the default client to be closed")
+	}
+}
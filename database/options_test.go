@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm/logger"
+
+	"github.com/pilinux/gorest/config"
+)
+
+func TestInitDBWithOptions(t *testing.T) {
+	defer CloseDB()
+
+	silent := logger.Default.LogMode(logger.Silent)
+	db, err := InitDBWithOptions(
+		WithDriver("sqlite3"),
+		WithDSN(filepath.Join(t.TempDir(), "options.db")),
+		WithLogger(silent),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if GetDB() != db {
+		t.Error("expected the default connection to be replaced")
+	}
+	if db.Logger != silent {
+		t.Error("expected the custom logger")
+	}
+
+	// sqlite3 keeps the default pool, check the settings of the option
+	var o dbOptions
+	WithPoolConfig(PoolConfig{MaxIdleConns: 2, MaxOpenConns: 3, ConnMaxLifetime: time.Hour})(&o)
+	if o.config.Conn.MaxIdleConns != 2 || o.config.Conn.MaxOpenConns != 3 || o.config.Conn.ConnMaxLifetime != time.Hour {
+		t.Errorf("unexpected pool settings %+v", o.config.Conn)
+	}
+}
+
+func TestOpenDBWithDSNErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		driver string
+		dsn    string
+		want   string
+	}{
+		{name: "unsupported driver", driver: "oracle", dsn: "oracle://localhost", want: "error code: 164"},
+		{name: "invalid mysql DSN", driver: "mysql", dsn: "user:secret@localhost", want: "error code: 151"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := openDB(context.Background(), config.RDBMS{}, WithDriver(tc.driver), WithDSN(tc.dsn))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected %s, got %v", tc.want, err)
+			}
+			if err != nil && strings.Contains(err.Error(), "secret") {
+				t.Errorf("expected the password to be hidden, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWithNowFunc(t *testing.T) {
+	frozen := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	db, err := InitTestDB(WithNowFunc(func() time.Time {
+		return frozen
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+
+	type stamped struct {
+		ID        uint
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+	if err := db.AutoMigrate(&stamped{}); err != nil {
+		t.Fatal(err)
+	}
+	record := stamped{}
+	if err := db.Create(&record).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !record.CreatedAt.Equal(frozen) || !record.UpdatedAt.Equal(frozen) {
+		t.Errorf("expected frozen timestamps, got %v and %v", record.CreatedAt, record.UpdatedAt)
+	}
+}
+
+func TestEnvDSN(t *testing.T) {
+	defer CloseDB()
+
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "env.db")
+	t.Setenv("GOREST_TEST_DSN", envPath)
+	defer func(name string) {
+		DSNEnvVar = name
+	}(DSNEnvVar)
+	DSNEnvVar = "GOREST_TEST_DSN"
+
+	configureDB := config.RDBMS{}
+	configureDB.Env.Driver = DriverSQLite
+	configureDB.Access.DbName = filepath.Join(dir, "config.db")
+
+	var o dbOptions
+	for _, opt := range envDSNOptions() {
+		opt(&o)
+	}
+	if o.dsn != envPath || o.dsnSource != "env GOREST_TEST_DSN" {
+		t.Errorf("unexpected options %q from %q", o.dsn, o.dsnSource)
+	}
+
+	db, err := initDB(context.Background(), configureDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file string
+	if err := db.Raw("SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&file).Error; err != nil {
+		t.Fatal(err)
+	}
+	if file != envPath {
+		t.Errorf("expected the database of the env var, got %q", file)
+	}
+
+	// an explicit DSN takes precedence
+	optionPath := filepath.Join(dir, "option.db")
+	db, err = initDB(context.Background(), configureDB, WithDSN(optionPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Raw("SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&file).Error; err != nil {
+		t.Fatal(err)
+	}
+	if file != optionPath {
+		t.Errorf("expected the database of the option, got %q", file)
+	}
+
+	DSNEnvVar = ""
+	if opts := envDSNOptions(); len(opts) != 0 {
+		t.Error("expected no option when DSNEnvVar is empty")
+	}
+}
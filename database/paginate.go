@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultPerPage - page size of Paginate when perPage is not set
+var DefaultPerPage = 20
+
+// MaxPerPage - largest page size of Paginate, larger values are capped
+var MaxPerPage = 100
+
+// Paginate - fetch the page of the filtered query db into dest, a
+// pointer to a slice, and return the number of records matching the
+// query
+//
+// page starts at 1, smaller values select the first page. perPage
+// defaults to DefaultPerPage and is capped at MaxPerPage. Use a stable
+// order, e.g. by primary key, so that pages do not overlap.
+//
+//	var posts []model.Post
+//	total, err := database.Paginate(ctx, db.Where("user_id = ?", id).Order("id"), page, perPage, &posts)
+func Paginate(ctx context.Context, db *gorm.DB, page, perPage int, dest interface{}) (total int64, err error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage <= 0 {
+		perPage = DefaultPerPage
+	}
+	if perPage > MaxPerPage {
+		perPage = MaxPerPage
+	}
+
+	tx := db.WithContext(ctx)
+	if tx.Statement.Model == nil && tx.Statement.Table == "" {
+		tx = tx.Model(dest)
+	}
+
+	// the count must not leak its clauses into the query of the page
+	if err = tx.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	err = tx.Session(&gorm.Session{}).
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(dest).Error
+
+	return total, err
+}
+
+// PaginateKeyset - fetch the next limit records of the filtered query
+// db after the cursor into dest, a pointer to a slice, and return the
+// cursor of the following page
+//
+// cursorColumn is a unique column in ascending order, e.g. "id", or in
+// descending order with the suffix desc, e.g. "id desc". A nil
+// afterValue selects the first page. nextCursor is the value of the
+// column in the last record, nil when there is no further page. The
+// query is WHERE column > afterValue ORDER BY column LIMIT limit (< in
+// descending order), which uses the index of the column instead of
+// scanning the skipped rows like OFFSET.
+//
+// limit defaults to DefaultPerPage and is capped at MaxPerPage.
+//
+//	var posts []model.Post
+//	next, err := database.PaginateKeyset(ctx, db.Where("user_id = ?", id), "id desc", cursor, 50, &posts)
+func PaginateKeyset(ctx context.Context, db *gorm.DB, cursorColumn string, afterValue interface{}, limit int, dest interface{}) (nextCursor interface{}, err error) {
+	fields := strings.Fields(cursorColumn)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, errors.New("invalid cursor column '" + cursorColumn + "'")
+	}
+	column := fields[0]
+	desc := false
+	if len(fields) == 2 {
+		switch strings.ToLower(fields[1]) {
+		case "asc":
+		case "desc":
+			desc = true
+		default:
+			return nil, errors.New("invalid order '" + fields[1] + "' of the cursor column, expected asc or desc")
+		}
+	}
+
+	if limit <= 0 {
+		limit = DefaultPerPage
+	}
+	if limit > MaxPerPage {
+		limit = MaxPerPage
+	}
+
+	tx := db.WithContext(ctx).Session(&gorm.Session{})
+	col := clause.Column{Name: column}
+	if afterValue != nil {
+		if desc {
+			tx = tx.Where(clause.Lt{Column: col, Value: afterValue})
+		} else {
+			tx = tx.Where(clause.Gt{Column: col, Value: afterValue})
+		}
+	}
+	tx = tx.Order(clause.OrderByColumn{Column: col, Desc: desc}).Limit(limit).Find(dest)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	// a short page is the last one
+	rows := reflect.Indirect(reflect.ValueOf(dest))
+	if rows.Kind() != reflect.Slice || rows.Len() < limit {
+		return nil, nil
+	}
+
+	return keysetCursor(ctx, tx, rows.Index(rows.Len()-1), column)
+}
+
+// keysetCursor - value of column in the record row
+func keysetCursor(ctx context.Context, tx *gorm.DB, row reflect.Value, column string) (interface{}, error) {
+	// the name of the column without the table, e.g. posts.id
+	name := column[strings.LastIndex(column, ".")+1:]
+
+	row = reflect.Indirect(row)
+	if row.Kind() == reflect.Map {
+		value := row.MapIndex(reflect.ValueOf(name))
+		if !value.IsValid() {
+			return nil, errors.New("cursor column '" + name + "' is missing in the result")
+		}
+		return value.Interface(), nil
+	}
+
+	// dest may be another struct than the model of the query
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(reflect.New(row.Type()).Interface()); err != nil {
+		return nil, err
+	}
+	field := stmt.Schema.LookUpField(name)
+	if field == nil {
+		return nil, errors.New("cursor column '" + name + "' is not a field of " + stmt.Schema.Name)
+	}
+	value, _ := field.ValueOf(ctx, row)
+
+	return value, nil
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+type pageItem struct {
+	ID   uint
+	Name string
+}
+
+// initTestPageItems - in-memory database with n items
+func initTestPageItems(t *testing.T, n int) {
+	t.Helper()
+
+	db, err := InitTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = CloseDB()
+	})
+	if err := db.AutoMigrate(&pageItem{}); err != nil {
+		t.Fatal(err)
+	}
+	items := make([]pageItem, n)
+	for i := range items {
+		items[i].Name = fmt.Sprintf("item-%d", i+1)
+	}
+	if err := db.Create(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	initTestPageItems(t, 250)
+
+	previousMax := MaxPerPage
+	MaxPerPage = 100
+	defer func() {
+		MaxPerPage = previousMax
+	}()
+
+	testCases := []struct {
+		name      string
+		page      int
+		perPage   int
+		wantLen   int
+		wantFirst uint
+	}{
+		{name: "first page", page: 1, perPage: 10, wantLen: 10, wantFirst: 1},
+		{name: "second page", page: 2, perPage: 10, wantLen: 10, wantFirst: 11},
+		{name: "page 0", page: 0, perPage: 10, wantLen: 10, wantFirst: 1},
+		{name: "negative page", page: -3, perPage: 10, wantLen: 10, wantFirst: 1},
+		{name: "default per page", page: 1, perPage: 0, wantLen: DefaultPerPage, wantFirst: 1},
+		{name: "per page over max", page: 1, perPage: 1000, wantLen: 100, wantFirst: 1},
+		{name: "last partial page", page: 3, perPage: 100, wantLen: 50, wantFirst: 201},
+		{name: "beyond the last page", page: 10, perPage: 100, wantLen: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var items []pageItem
+			total, err := Paginate(context.Background(), GetDB().Order("id"), tc.page, tc.perPage, &items)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 250 {
+				t.Errorf("expected total 250, got %d", total)
+			}
+			if len(items) != tc.wantLen {
+				t.Fatalf("expected %d items, got %d", tc.wantLen, len(items))
+			}
+			if tc.wantLen > 0 && items[0].ID != tc.wantFirst {
+				t.Errorf("expected first id %d, got %d", tc.wantFirst, items[0].ID)
+			}
+		})
+	}
+}
+
+func TestPaginateFiltered(t *testing.T) {
+	initTestPageItems(t, 30)
+
+	var items []pageItem
+	query := GetDB().Model(&pageItem{}).Where("id > ?", 25).Order("id")
+	total, err := Paginate(context.Background(), query, 1, 2, &items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 5 || len(items) != 2 || items[0].ID != 26 {
+		t.Errorf("unexpected page: total %d, items %v", total, items)
+	}
+}
+
+func TestPaginateKeyset(t *testing.T) {
+	initTestPageItems(t, 25)
+	ctx := context.Background()
+
+	testCases := []struct {
+		name   string
+		column string
+		want   []uint
+	}{
+		{name: "ascending", column: "id", want: []uint{1, 11, 21}},
+		{name: "descending", column: "id DESC", want: []uint{25, 15, 5}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var firsts []uint
+			var cursor interface{}
+			for page := 0; ; page++ {
+				if page > 5 {
+					t.Fatal("expected the pagination to stop")
+				}
+				var items []pageItem
+				next, err := PaginateKeyset(ctx, GetDB(), tc.column, cursor, 10, &items)
+				if err != nil {
+					t.Fatal(err)
+				}
+				firsts = append(firsts, items[0].ID)
+				if next == nil {
+					break
+				}
+				cursor = next
+			}
+			if fmt.Sprint(firsts) != fmt.Sprint(tc.want) {
+				t.Errorf("expected first ids %v, got %v", tc.want, firsts)
+			}
+		})
+	}
+
+	// other destination than the model, with a filter
+	var names []struct {
+		ID   uint
+		Name string
+	}
+	query := GetDB().Model(&pageItem{}).Where("id <= ?", 20)
+	next, err := PaginateKeyset(ctx, query, "id", 5, 10, &names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 10 || names[0].ID != 6 || next != uint(15) {
+		t.Errorf("unexpected page %v, next %v", names, next)
+	}
+
+	// map destination
+	var rows []map[string]interface{}
+	next, err = PaginateKeyset(ctx, GetDB().Table("page_items"), "id", nil, 2, &rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || fmt.Sprint(next) != "2" {
+		t.Errorf("unexpected page %v, next %v", rows, next)
+	}
+
+	for _, column := range []string{"", "id sideways", "id desc extra"} {
+		var items []pageItem
+		if _, err := PaginateKeyset(ctx, GetDB(), column, nil, 10, &items); err == nil {
+			t.Errorf("expected error for the cursor column %q", column)
+		}
+	}
+}
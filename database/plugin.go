@@ -0,0 +1,65 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// plugins registered with RegisterPlugin
+var (
+	pluginsMu sync.RWMutex
+	plugins   []gorm.Plugin
+)
+
+// RegisterPlugin - register a GORM plugin, e.g. audit or encryption
+// callbacks, which is applied to the connections opened afterwards by
+// InitDB, InitNamedDB and ReconnectDB
+//
+// When the default connection is already open, the plugin is applied
+// to it immediately. Registering two plugins with the same name
+// returns gorm.ErrRegistered.
+func RegisterPlugin(p gorm.Plugin) error {
+	if p == nil {
+		return errors.New("plugin is required")
+	}
+
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	for _, registered := range plugins {
+		if registered.Name() == p.Name() {
+			return fmt.Errorf("plugin %s: %w", p.Name(), gorm.ErrRegistered)
+		}
+	}
+	if db := currentDB(); db != nil {
+		if err := db.Use(p); err != nil {
+			return fmt.Errorf("plugin %s: %w", p.Name(), err)
+		}
+	}
+	plugins = append(plugins, p)
+
+	return nil
+}
+
+// registeredPlugins - copy of the plugins registered with
+// RegisterPlugin
+func registeredPlugins() []gorm.Plugin {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+
+	return append([]gorm.Plugin(nil), plugins...)
+}
+
+// usePlugins - apply the plugins to db in order
+func usePlugins(db *gorm.DB, plugins []gorm.Plugin) error {
+	for _, p := range plugins {
+		if err := db.Use(p); err != nil {
+			return fmt.Errorf("plugin %s: %w", p.Name(), err)
+		}
+	}
+
+	return nil
+}
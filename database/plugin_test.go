@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// testPlugin - counts the created records
+type testPlugin struct {
+	name    string
+	created *int
+}
+
+func (p testPlugin) Name() string {
+	return p.name
+}
+
+func (p testPlugin) Initialize(db *gorm.DB) error {
+	return db.Callback().Create().After("gorm:create").Register(p.name, func(*gorm.DB) {
+		*p.created++
+	})
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	defer func() {
+		plugins = nil
+		_ = CloseDB()
+	}()
+	_ = CloseDB()
+
+	var registered, optional int
+	if err := RegisterPlugin(testPlugin{name: "test:registered", created: &registered}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterPlugin(testPlugin{name: "test:registered", created: &registered}); !errors.Is(err, gorm.ErrRegistered) {
+		t.Errorf("expected gorm.ErrRegistered, got %v", err)
+	}
+	if err := RegisterPlugin(nil); err == nil {
+		t.Error("expected error for a nil plugin")
+	}
+
+	configureDB := config.RDBMS{}
+	configureDB.Env.Driver = "sqlite3"
+	configureDB.Access.DbName = filepath.Join(t.TempDir(), "plugin.db")
+	db, err := InitDBWithOptions(
+		WithConfig(configureDB),
+		WithPlugins(testPlugin{name: "test:optional", created: &optional}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type item struct {
+		ID uint
+	}
+	if err := db.AutoMigrate(&item{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&item{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if registered != 1 || optional != 1 {
+		t.Errorf("expected both plugins to run once, got %d and %d", registered, optional)
+	}
+
+	// applied to the open connection immediately
+	var late int
+	if err := RegisterPlugin(testPlugin{name: "test:late", created: &late}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&item{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if late != 1 {
+		t.Errorf("expected the late plugin to run once, got %d", late)
+	}
+
+	// a failing plugin closes the connection
+	if _, err := openDB(context.Background(), configureDB, WithPlugins(testPlugin{name: "test:late", created: &late})); !errors.Is(err, gorm.ErrRegistered) {
+		t.Errorf("expected gorm.ErrRegistered, got %v", err)
+	}
+}
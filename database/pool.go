@@ -0,0 +1,89 @@
+package database
+
+import (
+	"runtime"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// RecommendedPoolConfig - pool settings sized by the number of CPUs of
+// the application and the cost of a connection on the server
+//
+// - postgres, cockroachdb, sqlserver: 4 connections per CPU, a
+// server connection is a process or a heavy session
+//
+// - mysql: 8 connections per CPU, a server connection is a thread
+//
+// - clickhouse: 2 connections per CPU, the queries are CPU bound on
+// the server
+//
+// The number of open connections is capped at 100, half of them are
+// kept idle. The connections of a server are recycled after 30 minutes
+// and closed after 5 idle minutes. sqlite3 keeps the defaults of
+// database/sql: unlimited open and 2 idle connections, no lifetime.
+func RecommendedPoolConfig(driver string) PoolConfig {
+	perCPU := 0
+	switch driver {
+	case DriverPostgres, DriverCockroachDB, DriverSQLServer:
+		perCPU = 4
+	case DriverMySQL:
+		perCPU = 8
+	case DriverClickHouse:
+		perCPU = 2
+	default:
+		return PoolConfig{MaxIdleConns: 2}
+	}
+
+	maxOpen := min(perCPU*runtime.NumCPU(), 100)
+	return PoolConfig{
+		MaxIdleConns:    max(maxOpen/2, 2),
+		MaxOpenConns:    maxOpen,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+	}
+}
+
+// withRecommendedPool - configureDB with the pool settings left at
+// zero replaced by RecommendedPoolConfig, the chosen values are logged
+func withRecommendedPool(configureDB config.RDBMS) config.RDBMS {
+	recommended := RecommendedPoolConfig(configureDB.Env.Driver)
+	conn := &configureDB.Conn
+	fields := log.Fields{}
+
+	if conn.MaxIdleConns == 0 && recommended.MaxIdleConns != 0 {
+		conn.MaxIdleConns = recommended.MaxIdleConns
+		fields["maxIdleConns"] = conn.MaxIdleConns
+	}
+	if conn.MaxOpenConns == 0 && recommended.MaxOpenConns != 0 {
+		conn.MaxOpenConns = recommended.MaxOpenConns
+		fields["maxOpenConns"] = conn.MaxOpenConns
+	}
+	if conn.ConnMaxLifetime == 0 && recommended.ConnMaxLifetime != 0 {
+		conn.ConnMaxLifetime = recommended.ConnMaxLifetime
+		fields["connMaxLifetime"] = conn.ConnMaxLifetime
+	}
+	if conn.ConnMaxIdleTime == 0 && recommended.ConnMaxIdleTime != 0 {
+		conn.ConnMaxIdleTime = recommended.ConnMaxIdleTime
+		fields["connMaxIdleTime"] = conn.ConnMaxIdleTime
+	}
+
+	if len(fields) > 0 {
+		fields["driver"] = configureDB.Env.Driver
+		log.WithFields(fields).Info("database: using the recommended pool settings")
+	}
+
+	return configureDB
+}
+
+// maxIdleConnsOf - max number of idle connections of the pool opened
+// for configureDB
+func maxIdleConnsOf(configureDB config.RDBMS) int {
+	if configureDB.Conn.MaxIdleConns != 0 {
+		return configureDB.Conn.MaxIdleConns
+	}
+
+	return RecommendedPoolConfig(configureDB.Env.Driver).MaxIdleConns
+}
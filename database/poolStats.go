@@ -0,0 +1,89 @@
+package database
+
+import (
+	"time"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// PoolStatsDTO - snapshot of the connection pools of the initialized
+// databases, e.g. for an admin dashboard
+type PoolStatsDTO struct {
+	RDBMS *SQLPoolStatsDTO   `json:"rdbms,omitempty"`
+	Redis *RedisPoolStatsDTO `json:"redis,omitempty"`
+	Mongo *MongoPoolStatsDTO `json:"mongo,omitempty"`
+}
+
+// SQLPoolStatsDTO - sql.DBStats of the default relational database
+//
+// WaitDuration is encoded in nanoseconds.
+type SQLPoolStatsDTO struct {
+	MaxOpenConnections int           `json:"maxOpenConnections"`
+	OpenConnections    int           `json:"openConnections"`
+	InUse              int           `json:"inUse"`
+	Idle               int           `json:"idle"`
+	WaitCount          int64         `json:"waitCount"`
+	WaitDuration       time.Duration `json:"waitDuration"`
+	MaxIdleClosed      int64         `json:"maxIdleClosed"`
+	MaxIdleTimeClosed  int64         `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed  int64         `json:"maxLifetimeClosed"`
+}
+
+// RedisPoolStatsDTO - connections of the redis pools
+type RedisPoolStatsDTO struct {
+	PoolSize        int64 `json:"poolSize"`
+	OpenConnections int64 `json:"openConnections"`
+}
+
+// MongoPoolStatsDTO - connections of the mongo pool
+type MongoPoolStatsDTO struct {
+	MaxPoolSize     uint64 `json:"maxPoolSize"`
+	OpenConnections int64  `json:"openConnections"`
+	InUse           int64  `json:"inUse"`
+}
+
+// PoolStats - snapshot of the connection pools, the databases which
+// are not initialized are omitted
+//
+//	r.GET("admin/pools", func(c *gin.Context) {
+//		c.JSON(http.StatusOK, database.PoolStats())
+//	})
+func PoolStats() PoolStatsDTO {
+	var stats PoolStatsDTO
+
+	if db := currentDB(); db != nil {
+		if sqlDB, err := db.DB(); err == nil {
+			s := sqlDB.Stats()
+			stats.RDBMS = &SQLPoolStatsDTO{
+				MaxOpenConnections: s.MaxOpenConnections,
+				OpenConnections:    s.OpenConnections,
+				InUse:              s.InUse,
+				Idle:               s.Idle,
+				WaitCount:          s.WaitCount,
+				WaitDuration:       s.WaitDuration,
+				MaxIdleClosed:      s.MaxIdleClosed,
+				MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+				MaxLifetimeClosed:  s.MaxLifetimeClosed,
+			}
+		}
+	}
+
+	if client := currentRedis(); client != nil && *client != nil {
+		stats.Redis = &RedisPoolStatsDTO{
+			PoolSize:        redisPoolSize.Load(),
+			OpenConnections: redisConnsOpen.Load(),
+		}
+	}
+
+	if currentMongo() != nil {
+		stats.Mongo = &MongoPoolStatsDTO{
+			OpenConnections: mongoConnsOpen.Load(),
+			InUse:           mongoConnsInUse.Load(),
+		}
+		if cfg := config.GetConfig(); cfg != nil {
+			stats.Mongo.MaxPoolSize = cfg.Database.MongoDB.Env.PoolSize
+		}
+	}
+
+	return stats
+}
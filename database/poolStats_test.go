@@ -0,0 +1,43 @@
+package database
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPoolStats(t *testing.T) {
+	_ = CloseDB()
+	if stats := PoolStats(); stats.RDBMS != nil {
+		t.Errorf("expected no rdbms stats without database, got %+v", stats.RDBMS)
+	}
+
+	db, err := InitTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+	initTestRedis(t)
+
+	tx := db.Begin()
+	defer tx.Rollback()
+
+	stats := PoolStats()
+	if stats.RDBMS == nil || stats.RDBMS.InUse != 1 || stats.RDBMS.OpenConnections < 1 {
+		t.Errorf("expected 1 connection in use, got %+v", stats.RDBMS)
+	}
+	if stats.Redis == nil {
+		t.Error("expected redis stats")
+	}
+	if stats.Mongo != nil {
+		t.Errorf("expected no mongo stats without client, got %+v", stats.Mongo)
+	}
+
+	b, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"inUse":1`) || strings.Contains(string(b), `"mongo"`) {
+		t.Errorf("unexpected JSON %s", b)
+	}
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/pilinux/gorest/config"
+)
+
+func TestRecommendedPoolConfig(t *testing.T) {
+	for _, driver := range []string{DriverMySQL, DriverPostgres, DriverSQLServer, DriverClickHouse} {
+		pool := RecommendedPoolConfig(driver)
+		if pool.MaxOpenConns <= 0 || pool.MaxOpenConns > 100 || pool.MaxOpenConns > 8*runtime.NumCPU() {
+			t.Errorf("%s: unexpected max open connections %d", driver, pool.MaxOpenConns)
+		}
+		if pool.MaxIdleConns < 2 || pool.MaxIdleConns > max(pool.MaxOpenConns, 2) {
+			t.Errorf("%s: unexpected max idle connections %d", driver, pool.MaxIdleConns)
+		}
+		if pool.ConnMaxLifetime <= 0 || pool.ConnMaxIdleTime <= 0 {
+			t.Errorf("%s: expected the connections to be recycled, got %+v", driver, pool)
+		}
+	}
+	if pool := RecommendedPoolConfig(DriverMySQL); pool.MaxOpenConns < RecommendedPoolConfig(DriverPostgres).MaxOpenConns {
+		t.Errorf("expected more mysql than postgres connections, got %d", pool.MaxOpenConns)
+	}
+
+	if pool := RecommendedPoolConfig(DriverSQLite); pool != (PoolConfig{MaxIdleConns: 2}) {
+		t.Errorf("expected the defaults of database/sql for sqlite3, got %+v", pool)
+	}
+}
+
+func TestWithRecommendedPool(t *testing.T) {
+	configureDB := config.RDBMS{}
+	configureDB.Env.Driver = DriverPostgres
+	configureDB.Conn.MaxOpenConns = 7
+	configureDB.Conn.ConnMaxLifetime = time.Hour
+
+	recommended := RecommendedPoolConfig(DriverPostgres)
+	got := withRecommendedPool(configureDB).Conn
+	if got.MaxOpenConns != 7 || got.ConnMaxLifetime != time.Hour {
+		t.Errorf("expected the configured values to be kept, got %+v", got)
+	}
+	if got.MaxIdleConns != recommended.MaxIdleConns || got.ConnMaxIdleTime != recommended.ConnMaxIdleTime {
+		t.Errorf("expected the recommended values for the zero fields, got %+v", got)
+	}
+	if maxIdleConnsOf(configureDB) != recommended.MaxIdleConns {
+		t.Errorf("expected %d idle connections, got %d", recommended.MaxIdleConns, maxIdleConnsOf(configureDB))
+	}
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mediocregopher/radix/v4"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// Message - message received on a subscribed redis channel
+type Message struct {
+	Channel string
+	Payload []byte
+}
+
+// Publish - send payload to the subscribers of channel
+func Publish(ctx context.Context, channel string, payload []byte) error {
+	client, err := cacheClient()
+	if err != nil {
+		return err
+	}
+
+	return client.Do(ctx, radix.Cmd(nil, "PUBLISH", channel, string(payload)))
+}
+
+// Subscribe - subscribe to the redis channels and receive their
+// messages until ctx is cancelled
+//
+// The subscription uses its own connection to the server of the redis
+// client (the current master with Redis Sentinel). When the connection
+// drops, it is reopened and the channels are subscribed again, the
+// messages published in between are lost. The returned channel is
+// closed once ctx is done.
+//
+//	messages, err := database.Subscribe(ctx, "notifications")
+//	if err != nil {
+//		return err
+//	}
+//	for msg := range messages {
+//		notify(msg.Payload)
+//	}
+func Subscribe(ctx context.Context, channels ...string) (<-chan Message, error) {
+	if len(channels) == 0 {
+		return nil, errors.New("redis: no channel to subscribe to")
+	}
+	if _, err := cacheClient(); err != nil {
+		return nil, err
+	}
+
+	dialer, err := pubSubDialer(config.GetConfig())
+	if err != nil {
+		return nil, err
+	}
+	conn, err := (radix.PersistentPubSubConnConfig{Dialer: dialer}).New(ctx, func() (string, string, error) {
+		// follows the failovers of the client
+		addr := GetRedisAddr()
+		if addr == "" {
+			return "", "", ErrRedisNotInitialized
+		}
+		return "tcp", addr, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Subscribe(ctx, channels...); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	messages := make(chan Message)
+	go func() {
+		defer close(messages)
+		defer conn.Close()
+
+		for {
+			msg, err := conn.Next(ctx)
+			if err != nil {
+				// the connection is reopened by Next, it only fails
+				// once ctx is done
+				if ctx.Err() == nil {
+					log.WithError(err).Error("redis: subscription closed")
+				}
+				return
+			}
+			select {
+			case messages <- Message{Channel: msg.Channel, Payload: msg.Message}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return messages, nil
+}
+
+// pubSubDialer - dialer of the subscriptions with the credentials and
+// the TLS settings of the redis config
+func pubSubDialer(cfg *config.Configuration) (radix.Dialer, error) {
+	if cfg == nil {
+		return radix.Dialer{}, nil
+	}
+
+	dialer, err := redisDialer(cfg.Database.REDIS)
+	if err != nil {
+		return dialer, err
+	}
+	// a subscription waits for messages without a read deadline and
+	// must not be recycled after REDIS_MAX_LIFETIME
+	dialer.CustomConn = nil
+
+	return dialer, nil
+}
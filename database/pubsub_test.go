@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPubSub(t *testing.T) {
+	if _, err := Subscribe(context.Background(), "news"); !errors.Is(err, ErrRedisNotInitialized) {
+		t.Errorf("expected ErrRedisNotInitialized, got %v", err)
+	}
+
+	s := initTestRedis(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := Subscribe(ctx); err == nil {
+		t.Error("expected an error without channel")
+	}
+
+	subCtx, stop := context.WithCancel(ctx)
+	messages, err := Subscribe(subCtx, "news", "alerts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receive := func(want Message) {
+		t.Helper()
+		// published until the subscription receives it, the pool
+		// reconnects after a restart of the server
+		for {
+			_ = Publish(ctx, want.Channel, want.Payload)
+			select {
+			case msg := <-messages:
+				if msg.Channel != want.Channel || string(msg.Payload) != string(want.Payload) {
+					t.Errorf("expected %+v, got %+v", want, msg)
+				}
+				return
+			case <-time.After(100 * time.Millisecond):
+			case <-ctx.Done():
+				t.Fatalf("expected message %+v", want)
+			}
+		}
+	}
+	receive(Message{Channel: "news", Payload: []byte("hello")})
+	receive(Message{Channel: "alerts", Payload: []byte{0, 1, 2}})
+
+	// the subscription survives a restart of the server
+	s.Close()
+	if err := s.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	receive(Message{Channel: "news", Payload: []byte("again")})
+
+	stop()
+	select {
+	case _, ok := <-messages:
+		for ok {
+			_, ok = <-messages
+		}
+	case <-ctx.Done():
+		t.Fatal("expected the channel to be closed")
+	}
+}
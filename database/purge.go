@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// HardDeleteOlderThan - permanently delete the records of model whose
+// column is older than age, soft deleted records included, and return
+// the number of deleted rows
+//
+// The current time is taken from the NowFunc of the connection. With
+// column deleted_at, only the records soft deleted before the cutoff
+// are purged, e.g. for a retention policy:
+//
+//	n, err := database.HardDeleteOlderThan(ctx, &model.Post{}, "deleted_at", 30*24*time.Hour)
+func HardDeleteOlderThan(ctx context.Context, model interface{}, column string, age time.Duration) (int64, error) {
+	// an empty condition would delete the whole table
+	if strings.TrimSpace(column) == "" {
+		return 0, errors.New("column is required")
+	}
+	if age <= 0 {
+		return 0, errors.New("age must be greater than 0")
+	}
+
+	db := GetDB()
+	if db == nil {
+		return 0, ErrDBNotInitialized
+	}
+
+	cutoff := db.NowFunc().Add(-age)
+	result := db.WithContext(ctx).
+		Unscoped().
+		Where(clause.Lt{Column: clause.Column{Name: column}, Value: cutoff}).
+		Delete(model)
+
+	return result.RowsAffected, result.Error
+}
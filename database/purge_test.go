@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestHardDeleteOlderThan(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	db, err := InitTestDB(WithNowFunc(func() time.Time {
+		return now
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+
+	type purgeItem struct {
+		ID        uint
+		Name      string
+		DeletedAt gorm.DeletedAt
+	}
+	if err := db.AutoMigrate(&purgeItem{}); err != nil {
+		t.Fatal(err)
+	}
+	items := []purgeItem{
+		{Name: "active"},
+		{Name: "deleted long ago", DeletedAt: gorm.DeletedAt{Time: now.Add(-60 * 24 * time.Hour), Valid: true}},
+		{Name: "deleted recently", DeletedAt: gorm.DeletedAt{Time: now.Add(-time.Hour), Valid: true}},
+	}
+	if err := db.Create(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := HardDeleteOlderThan(ctx, &purgeItem{}, " ", time.Hour); err == nil {
+		t.Error("expected error for an empty column")
+	}
+	if _, err := HardDeleteOlderThan(ctx, &purgeItem{}, "deleted_at", 0); err == nil {
+		t.Error("expected error for a zero age")
+	}
+
+	n, err := HardDeleteOlderThan(ctx, &purgeItem{}, "deleted_at", 30*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 deleted row, got %d", n)
+	}
+
+	var names []string
+	if err := db.Unscoped().Model(&purgeItem{}).Order("id").Pluck("name", &names).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "active" || names[1] != "deleted recently" {
+		t.Errorf("unexpected remaining rows %v", names)
+	}
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// acquireTimeout - DBACQUIRE_TIMEOUT of the default connection
+var acquireTimeout atomic.Int64
+
+// WithQueryTimeout - bind db to a child context of ctx which expires
+// after timeout, call cancel once the queries are done
+//
+// DBQUERY_TIMEOUT is enforced by the server for postgres
+// (statement_timeout) and mysql (max_execution_time, read-only SELECT
+// only). Use WithQueryTimeout for SQL Server, ClickHouse and SQLite,
+// or for a per-request deadline. With timeout <= 0, the deadline of
+// ctx is kept.
+//
+// Example:
+//
+//	tx, cancel := database.WithQueryTimeout(c.Request.Context(), database.GetDB(), 5*time.Second)
+//	defer cancel()
+//	err := tx.Find(&users).Error
+func WithQueryTimeout(ctx context.Context, db *gorm.DB, timeout time.Duration) (*gorm.DB, context.CancelFunc) {
+	if timeout <= 0 {
+		ctx, cancel := context.WithCancel(ctx)
+		return db.WithContext(ctx), cancel
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return db.WithContext(ctx), cancel
+}
+
+// DBWithAcquireTimeout - default connection bound to a child context
+// of ctx which expires after DBACQUIRE_TIMEOUT, call cancel once the
+// queries are done, nil when the database is not initialized
+//
+// When all DBMAXOPENCONNS connections are in use, a query waits for a
+// free connection. With the deadline, it fails with
+// context.DeadlineExceeded instead of blocking until a connection is
+// released, so that a saturated pool rejects the requests instead of
+// piling them up. database/sql can not bound the wait on its own, the
+// deadline also covers the execution of the queries: set
+// DBACQUIRE_TIMEOUT above the duration of the queries of a request.
+// Without DBACQUIRE_TIMEOUT, the deadline of ctx is kept.
+//
+//	db, cancel := database.DBWithAcquireTimeout(c.Request.Context())
+//	defer cancel()
+//	if err := db.Find(&users).Error; errors.Is(err, context.DeadlineExceeded) {
+//		c.AbortWithStatus(http.StatusServiceUnavailable)
+//		return
+//	}
+func DBWithAcquireTimeout(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	db := GetDB()
+	if db == nil {
+		return nil, func() {}
+	}
+
+	return WithQueryTimeout(requestContext(ctx), db, time.Duration(acquireTimeout.Load()))
+}
+
+// queryTimeoutMillis - timeout in milliseconds, rounded up so that
+// a sub-millisecond timeout does not disable the limit
+func queryTimeoutMillis(timeout time.Duration) string {
+	ms := (timeout + time.Millisecond - 1) / time.Millisecond
+	return strconv.FormatInt(int64(ms), 10)
+}
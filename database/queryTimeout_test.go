@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithQueryTimeout(t *testing.T) {
+	db, err := InitTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, cancel := WithQueryTimeout(context.Background(), db, time.Minute)
+	deadline, ok := tx.Statement.Context.Deadline()
+	if !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("expected a deadline within a minute, got %v", deadline)
+	}
+	cancel()
+	if err := tx.Exec("SELECT 1").Error; err == nil {
+		t.Error("expected error after cancel")
+	}
+
+	tx, cancel = WithQueryTimeout(context.Background(), db, 0)
+	defer cancel()
+	if _, ok := tx.Statement.Context.Deadline(); ok {
+		t.Error("expected no deadline")
+	}
+	if err := tx.Exec("SELECT 1").Error; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDBWithAcquireTimeout(t *testing.T) {
+	_ = CloseDB()
+	db, cancel := DBWithAcquireTimeout(context.Background())
+	cancel()
+	if db != nil {
+		t.Error("expected nil without database")
+	}
+
+	db, err := InitTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+	acquireTimeout.Store(int64(50 * time.Millisecond))
+	defer acquireTimeout.Store(0)
+
+	// saturated pool
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	conn, err := sqlDB.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, cancel := DBWithAcquireTimeout(context.Background())
+	defer cancel()
+	start := time.Now()
+	if err := tx.Exec("SELECT 1").Error; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the wait to be bounded, took %v", elapsed)
+	}
+
+	// released connection
+	_ = conn.Close()
+	tx, cancel = DBWithAcquireTimeout(context.Background())
+	defer cancel()
+	if err := tx.Exec("SELECT 1").Error; err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mediocregopher/radix/v4"
+)
+
+// rateLimitScript - count a request in the current window, the window
+// starts with the first request and expires after ARGV[1] milliseconds
+var rateLimitScript = radix.NewEvalScript(`
+local count = redis.call("INCR", KEYS[1])
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+// AllowN - count a request against the limit of key in a fixed window
+// shared by all instances of the application
+//
+// At most limit requests are allowed per window, the window starts
+// with the first request and resets after window. remaining and
+// resetAt are meant for the X-RateLimit-Remaining and
+// X-RateLimit-Reset headers. The key is prefixed with CacheKeyPrefix.
+//
+//	allowed, remaining, resetAt, err := database.AllowN(ctx, "ratelimit:user:"+id, 100, time.Minute)
+//	c.Header("X-RateLimit-Limit", "100")
+//	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+//	c.Header("X-RateLimit-Reset", strconv.FormatInt(resetAt.Unix(), 10))
+//	if !allowed {
+//		c.AbortWithStatus(http.StatusTooManyRequests)
+//	}
+func AllowN(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, remaining int, resetAt time.Time, err error) {
+	if limit <= 0 {
+		return false, 0, time.Time{}, errors.New("rate limit must be greater than 0")
+	}
+	if window <= 0 {
+		return false, 0, time.Time{}, errors.New("rate limit window must be greater than 0")
+	}
+
+	client, err := cacheClient()
+	if err != nil {
+		return false, 0, time.Time{}, err
+	}
+
+	var reply []int64
+	if err = client.Do(ctx, rateLimitScript.Cmd(&reply, []string{cacheKey(key)}, redisMillis(window))); err != nil {
+		return false, 0, time.Time{}, err
+	}
+	if len(reply) != 2 {
+		return false, 0, time.Time{}, errors.New("redis: unexpected reply of the rate limit script")
+	}
+	count, ttl := reply[0], reply[1]
+
+	resetAt = time.Now().Add(time.Duration(ttl) * time.Millisecond)
+	remaining = max(limit-int(count), 0)
+
+	return count <= int64(limit), remaining, resetAt, nil
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAllowN(t *testing.T) {
+	s := initTestRedis(t)
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		allowed, remaining, resetAt, err := AllowN(ctx, "user:1", 3, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !allowed || remaining != 3-i {
+			t.Errorf("request %d: expected allowed with %d remaining, got %v, %d", i, 3-i, allowed, remaining)
+		}
+		if until := time.Until(resetAt); until <= 0 || until > time.Minute {
+			t.Errorf("request %d: unexpected reset in %v", i, until)
+		}
+	}
+
+	allowed, remaining, _, err := AllowN(ctx, "user:1", 3, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowed || remaining != 0 {
+		t.Errorf("expected the 4th request to be denied, got %v, %d", allowed, remaining)
+	}
+
+	// other keys have their own counter
+	if allowed, _, _, err := AllowN(ctx, "user:2", 3, time.Minute); err != nil || !allowed {
+		t.Errorf("expected another key to be allowed, got %v, %v", allowed, err)
+	}
+
+	// just before the end of the window
+	s.FastForward(59 * time.Second)
+	if allowed, _, _, err := AllowN(ctx, "user:1", 3, time.Minute); err != nil || allowed {
+		t.Errorf("expected to be denied before the window ends, got %v, %v", allowed, err)
+	}
+
+	// a new window starts
+	s.FastForward(time.Second)
+	allowed, remaining, _, err = AllowN(ctx, "user:1", 3, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !allowed || remaining != 2 {
+		t.Errorf("expected a new window, got %v, %d", allowed, remaining)
+	}
+
+	if _, _, _, err := AllowN(ctx, "user:1", 0, time.Minute); err == nil {
+		t.Error("expected error for a zero limit")
+	}
+	if _, _, _, err := AllowN(ctx, "user:1", 1, 0); err == nil {
+		t.Error("expected error for a zero window")
+	}
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pilinux/gorest/config"
+)
+
+func TestReconnectDB(t *testing.T) {
+	cfg := config.RDBMS{}
+	cfg.Env.Driver = "sqlite3"
+	cfg.Access.DbName = filepath.Join(t.TempDir(), "reconnect.db")
+
+	if err := reconnectDB(cfg); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+
+	first := GetDB()
+	if first == nil || GetNamedDB(DefaultDBName) != first {
+		t.Fatal("expected the default connection to be set")
+	}
+	if err := first.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// GetDB must never return nil while reconnecting
+	var nilSeen atomic.Bool
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				if GetNamedDB(DefaultDBName) == nil {
+					nilSeen.Store(true)
+				}
+			}
+		}
+	}()
+
+	err := reconnectDB(cfg)
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nilSeen.Load() {
+		t.Error("the default connection was nil during the reconnect")
+	}
+
+	second := GetDB()
+	if second == first {
+		t.Fatal("expected a new connection pool")
+	}
+	if !second.Migrator().HasTable("items") {
+		t.Error("expected the new pool to see the existing table")
+	}
+	sqlDB, _ := first.DB()
+	if err := sqlDB.Ping(); err == nil {
+		t.Error("expected the old pool to be closed")
+	}
+
+	// a failed reconnect keeps the current pool
+	bad := config.RDBMS{}
+	bad.Env.Driver = "oracle"
+	if err := reconnectDB(bad); err == nil {
+		t.Error("expected error for an unsupported driver")
+	}
+	if GetDB() != second {
+		t.Error("expected the current pool to be kept after a failed reconnect")
+	}
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/mediocregopher/radix/v4"
+)
+
+// redisNetDialer - dial the network connections to redis with a
+// timeout, and set a write deadline before each write
+type redisNetDialer struct {
+	dialer interface {
+		DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+	}
+	writeTimeout time.Duration
+}
+
+func (d redisNetDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := d.dialer.DialContext(ctx, network, addr)
+	if err != nil || d.writeTimeout <= 0 {
+		return conn, err
+	}
+
+	return &writeDeadlineConn{Conn: conn, writeTimeout: d.writeTimeout}, nil
+}
+
+// writeDeadlineConn - net.Conn failing writes which do not complete
+// within writeTimeout
+type writeDeadlineConn struct {
+	net.Conn
+	writeTimeout time.Duration
+}
+
+func (c *writeDeadlineConn) Write(b []byte) (int, error) {
+	if err := c.Conn.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+		return 0, err
+	}
+
+	return c.Conn.Write(b)
+}
+
+// errRedisConnExpired - the connection reached REDIS_MAX_LIFETIME
+var errRedisConnExpired = errors.New("redis: connection reached its max lifetime")
+
+// redisConn - radix.Conn applying a read timeout to each command and
+// failing once it is older than maxLifetime
+//
+// The socket is never replaced while the pool hands the connection
+// out, an expired connection is closed and its commands return
+// errRedisConnExpired so that the radix pool discards it and dials a
+// new one. A transaction which crosses the lifetime boundary fails as
+// a whole, redis drops the queued commands when the socket closes.
+type redisConn struct {
+	radix.Conn
+	readTimeout time.Duration
+	expiresAt   time.Time // zero: no max lifetime
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+var _ radix.Conn = new(redisConn)
+
+// newRedisConn - wrap a connection which was just dialed
+func newRedisConn(conn radix.Conn, readTimeout, maxLifetime time.Duration) *redisConn {
+	c := &redisConn{Conn: conn, readTimeout: readTimeout}
+	if maxLifetime > 0 {
+		c.expiresAt = time.Now().Add(maxLifetime)
+	}
+
+	return c
+}
+
+// expired - the connection reached maxLifetime
+func (c *redisConn) expired() bool {
+	return !c.expiresAt.IsZero() && !time.Now().Before(c.expiresAt)
+}
+
+func (c *redisConn) Do(ctx context.Context, a radix.Action) error {
+	return a.Perform(ctx, c)
+}
+
+func (c *redisConn) EncodeDecode(ctx context.Context, marshal, unmarshalInto interface{}) error {
+	if c.expired() {
+		_ = c.Close()
+		return errRedisConnExpired
+	}
+
+	if c.readTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.readTimeout)
+		defer cancel()
+	}
+
+	return c.Conn.EncodeDecode(ctx, marshal, unmarshalInto)
+}
+
+func (c *redisConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.closeErr = c.Conn.Close()
+	})
+
+	return c.closeErr
+}
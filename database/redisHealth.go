@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// RedisHealthStatus - result of the last background health check of
+// redis
+type RedisHealthStatus struct {
+	// Healthy - the last PING succeeded, or the client was recreated
+	// successfully after a failed PING
+	Healthy bool
+	// CheckedAt - time of the last check, zero if no check has run
+	CheckedAt time.Time
+	// Err - error of the last failed PING, nil when healthy
+	Err error
+}
+
+// state of the background health check of redis
+var (
+	redisHealthMu      sync.Mutex
+	redisHealthStop    chan struct{}
+	redisHealthStopped chan struct{}
+	redisHealth        RedisHealthStatus
+)
+
+// GetRedisHealth - last known health status of redis
+//
+// The status is only updated when REDIS_HEALTH_CHECK_INTERVAL is set.
+func GetRedisHealth() RedisHealthStatus {
+	redisHealthMu.Lock()
+	defer redisHealthMu.Unlock()
+
+	return redisHealth
+}
+
+// setRedisHealth - store the result of a health check
+func setRedisHealth(err error) {
+	redisHealthMu.Lock()
+	defer redisHealthMu.Unlock()
+
+	redisHealth = RedisHealthStatus{
+		Healthy:   err == nil,
+		CheckedAt: time.Now(),
+		Err:       err,
+	}
+}
+
+// startRedisHealthCheck - ping redis every HealthCheckInterval in the
+// background until CloseRedis is called
+func startRedisHealthCheck(configureRedis config.REDIS) {
+	interval := configureRedis.Conn.HealthCheckInterval
+	if interval <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	redisHealthMu.Lock()
+	redisHealthStop = stop
+	redisHealthStopped = stopped
+	redisHealthMu.Unlock()
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				checkRedisHealth(stop, configureRedis, interval)
+			}
+		}
+	}()
+}
+
+// stopRedisHealthCheck - stop the background health check and wait
+// until it returns
+func stopRedisHealthCheck() {
+	redisHealthMu.Lock()
+	stop := redisHealthStop
+	stopped := redisHealthStopped
+	redisHealthStop = nil
+	redisHealthStopped = nil
+	redisHealthMu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-stopped
+	}
+}
+
+// checkRedisHealth - ping redis, the client is recreated when the
+// ping fails, the old one is closed after the swap
+func checkRedisHealth(stop <-chan struct{}, configureRedis config.REDIS, timeout time.Duration) {
+	// abort the ping and the reconnection when CloseRedis is called
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	pingCtx, pingCancel := context.WithTimeout(ctx, timeout)
+	err := pingRedis(pingCtx)
+	pingCancel()
+	if err == nil {
+		setRedisHealth(nil)
+		return
+	}
+	log.WithError(err).Warn("redis: health check failed, recreating the connection pool")
+
+	dialer, errDialer := redisDialer(configureRedis)
+	if errDialer != nil {
+		log.WithError(errDialer).Error("redis: failed to recreate the connection pool")
+		setRedisHealth(err)
+		return
+	}
+	rClient, errOpen := openRedis(ctx, configureRedis, dialer)
+	if errOpen != nil {
+		log.WithError(errOpen).Error("redis: failed to recreate the connection pool")
+		setRedisHealth(err)
+		return
+	}
+
+	redisMu.Lock()
+	old := redisClient
+	if old == nil {
+		// closed in the meantime
+		redisMu.Unlock()
+		_ = rClient.Close()
+		return
+	}
+	redisClient = &rClient
+	redisMu.Unlock()
+
+	if err := (*old).Close(); err != nil {
+		log.WithError(err).Warn("redis: failed to close the old connection pool")
+	}
+	setRedisHealth(nil)
+}
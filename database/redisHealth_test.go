@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// waitRedisHealth - wait until the health status matches healthy
+func waitRedisHealth(t *testing.T, healthy bool) RedisHealthStatus {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status := GetRedisHealth()
+		if !status.CheckedAt.IsZero() && status.Healthy == healthy {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected healthy %v, got %+v", healthy, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRedisHealthCheck(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	configureRedis := config.REDIS{}
+	configureRedis.Env.Host = s.Host()
+	configureRedis.Env.Port = s.Port()
+	configureRedis.Conn.PoolSize = 1
+	configureRedis.Conn.ConnTTL = 1
+	configureRedis.Conn.HealthCheckInterval = 20 * time.Millisecond
+
+	if _, err := initRedis(context.Background(), configureRedis); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseRedis()
+
+	waitRedisHealth(t, true)
+
+	// the server goes away, the client can not be recreated
+	s.Close()
+	if status := waitRedisHealth(t, false); status.Err == nil {
+		t.Error("expected the error of the failed ping")
+	}
+
+	// the client is recreated once the server is back
+	if err := s.Restart(); err != nil {
+		t.Fatalf("failed to restart miniredis: %v", err)
+	}
+	waitRedisHealth(t, true)
+	if err := CacheSet(context.Background(), "key", []byte("value"), 0); err != nil {
+		t.Errorf("expected a working client, got %v", err)
+	}
+
+	// CloseRedis stops the health check
+	if err := CloseRedis(); err != nil {
+		t.Fatal(err)
+	}
+	checkedAt := GetRedisHealth().CheckedAt
+	time.Sleep(100 * time.Millisecond)
+	if !GetRedisHealth().CheckedAt.Equal(checkedAt) {
+		t.Error("expected no health check after CloseRedis")
+	}
+}
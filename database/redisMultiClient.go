@@ -0,0 +1,33 @@
+package database
+
+import (
+	"net"
+
+	"github.com/mediocregopher/radix/v4"
+)
+
+// multiClient wraps a radix.MultiClient, such as a sentinel or
+// a cluster client, so that it satisfies the radix.Client interface
+type multiClient struct {
+	radix.MultiClient
+}
+
+// Addr returns the address of a primary instance
+//
+// With sentinel, it is the current master. With cluster, it is the
+// address of any of the primaries. It returns nil when no primary is
+// currently known.
+func (m multiClient) Addr() net.Addr {
+	clients, err := m.Clients()
+	if err != nil {
+		return nil
+	}
+
+	for _, replicaSet := range clients {
+		if replicaSet.Primary != nil {
+			return replicaSet.Primary.Addr()
+		}
+	}
+
+	return nil
+}
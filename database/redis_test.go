@@ -0,0 +1,275 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mediocregopher/radix/v4"
+
+	"github.com/pilinux/gorest/config"
+)
+
+func TestRedisDialerAuth(t *testing.T) {
+	testCases := []struct {
+		name      string
+		reqUser   string
+		reqPass   string
+		user      string
+		pass      string
+		expectErr bool
+	}{
+		{name: "no auth", expectErr: false},
+		{name: "requirepass", reqPass: "secret", pass: "secret", expectErr: false},
+		{name: "requirepass with wrong password", reqPass: "secret", pass: "wrong", expectErr: true},
+		{name: "requirepass without password", reqPass: "secret", expectErr: true},
+		{name: "acl user", reqUser: "app", reqPass: "secret", user: "app", pass: "secret", expectErr: false},
+		{name: "acl user with wrong user", reqUser: "app", reqPass: "secret", user: "other", pass: "secret", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := miniredis.RunT(t)
+			if tc.reqUser != "" {
+				s.RequireUserAuth(tc.reqUser, tc.reqPass)
+			} else if tc.reqPass != "" {
+				s.RequireAuth(tc.reqPass)
+			}
+
+			configureRedis := config.REDIS{}
+			configureRedis.Access.User = tc.user
+			configureRedis.Access.Pass = tc.pass
+
+			dialer, err := redisDialer(configureRedis)
+			if err != nil {
+				t.Fatalf("failed to build dialer: %v", err)
+			}
+
+			conn, err := dialer.Dial(context.Background(), "tcp", s.Addr())
+			if err == nil {
+				err = conn.Do(context.Background(), radix.Cmd(nil, "PING"))
+				_ = conn.Close()
+			}
+			if tc.expectErr && err == nil {
+				t.Errorf("expected error, got nil")
+			}
+			if !tc.expectErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRedisDialerSelectDB(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	configureRedis := config.REDIS{}
+	configureRedis.Conn.DB = 3
+
+	dialer, err := redisDialer(configureRedis)
+	if err != nil {
+		t.Fatalf("failed to build dialer: %v", err)
+	}
+
+	conn, err := dialer.Dial(context.Background(), "tcp", s.Addr())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.Do(context.Background(), radix.Cmd(nil, "SET", "key", "value")); err != nil {
+		t.Fatalf("failed to set key: %v", err)
+	}
+	if got, _ := s.DB(3).Get("key"); got != "value" {
+		t.Errorf("expected key in database 3, got %q", got)
+	}
+	if s.DB(0).Exists("key") {
+		t.Errorf("expected key not to exist in database 0")
+	}
+}
+
+func TestRedisDialerInvalidDB(t *testing.T) {
+	for _, db := range []int{-1, MaxRedisDB + 1} {
+		configureRedis := config.REDIS{}
+		configureRedis.Conn.DB = db
+
+		if _, err := redisDialer(configureRedis); err == nil {
+			t.Errorf("expected error for database index %d, got nil", db)
+		}
+	}
+
+	configureRedis := config.REDIS{}
+	configureRedis.Conn.DB = 1
+	configureRedis.Cluster.Addrs = []string{"127.0.0.1:7000"}
+	if _, err := redisDialer(configureRedis); err == nil {
+		t.Errorf("expected error for SELECT in cluster mode, got nil")
+	}
+}
+
+func TestRedisReconnect(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	configureRedis := config.REDIS{}
+	configureRedis.Conn.DialTimeout = time.Second
+	configureRedis.Conn.ReadTimeout = time.Second
+	configureRedis.Conn.WriteTimeout = time.Second
+	configureRedis.Conn.MaxLifetime = time.Minute
+
+	dialer, err := redisDialer(configureRedis)
+	if err != nil {
+		t.Fatalf("failed to build dialer: %v", err)
+	}
+
+	client, err := (radix.PoolConfig{Dialer: dialer, Size: 2}).New(context.Background(), "tcp", s.Addr())
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Do(context.Background(), radix.Cmd(nil, "SET", "key", "value")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// close all connections from the server side
+	s.Close()
+	if err := s.Restart(); err != nil {
+		t.Fatalf("failed to restart miniredis: %v", err)
+	}
+
+	var value string
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err = client.Do(context.Background(), radix.Cmd(&value, "PING"))
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("expected the pool to reconnect, got %v", err)
+	}
+	if value != "PONG" {
+		t.Errorf("expected PONG, got %q", value)
+	}
+}
+
+func TestRedisConnMaxLifetime(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	configureRedis := config.REDIS{}
+	configureRedis.Conn.MaxLifetime = 50 * time.Millisecond
+
+	dialer, err := redisDialer(configureRedis)
+	if err != nil {
+		t.Fatalf("failed to build dialer: %v", err)
+	}
+
+	conn, err := dialer.Dial(context.Background(), "tcp", s.Addr())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.Do(context.Background(), radix.Cmd(nil, "PING")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n := s.TotalConnectionCount(); n != 1 {
+		t.Fatalf("expected 1 connection, got %d", n)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	err = conn.Do(context.Background(), radix.Cmd(nil, "PING"))
+	if !errors.Is(err, errRedisConnExpired) {
+		t.Fatalf("expected errRedisConnExpired, got %v", err)
+	}
+	if n := s.TotalConnectionCount(); n != 1 {
+		t.Errorf("expected the connection not to be replaced, got %d connections", n)
+	}
+	waitRedisConnections(t, s, 0)
+}
+
+func TestRedisConnMaxLifetimeTransaction(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	configureRedis := config.REDIS{}
+	configureRedis.Conn.MaxLifetime = 50 * time.Millisecond
+
+	dialer, err := redisDialer(configureRedis)
+	if err != nil {
+		t.Fatalf("failed to build dialer: %v", err)
+	}
+
+	ctx := context.Background()
+	pool, err := radix.PoolConfig{Size: 1, Dialer: dialer}.New(ctx, "tcp", s.Addr())
+	if err != nil {
+		t.Fatalf("failed to create the pool: %v", err)
+	}
+	defer pool.Close()
+
+	// the lifetime ends between MULTI and EXEC
+	err = pool.Do(ctx, radix.WithConn("", func(ctx context.Context, c radix.Conn) error {
+		if err := c.Do(ctx, radix.Cmd(nil, "MULTI")); err != nil {
+			return err
+		}
+		if err := c.Do(ctx, radix.Cmd(nil, "SET", "key", "first")); err != nil {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+		return c.Do(ctx, radix.Cmd(nil, "EXEC"))
+	}))
+	if !errors.Is(err, errRedisConnExpired) {
+		t.Fatalf("expected errRedisConnExpired, got %v", err)
+	}
+	if s.Exists("key") {
+		t.Fatal("expected the transaction to be discarded")
+	}
+
+	// the pool dials a new connection for the next checkout
+	err = pool.Do(ctx, radix.WithConn("", func(ctx context.Context, c radix.Conn) error {
+		if err := c.Do(ctx, radix.Cmd(nil, "MULTI")); err != nil {
+			return err
+		}
+		if err := c.Do(ctx, radix.Cmd(nil, "SET", "key", "second")); err != nil {
+			return err
+		}
+		return c.Do(ctx, radix.Cmd(nil, "EXEC"))
+	}))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if value, _ := s.Get("key"); value != "second" {
+		t.Errorf("expected second, got %q", value)
+	}
+	if n := s.TotalConnectionCount(); n != 2 {
+		t.Errorf("expected a new connection, got %d connections", n)
+	}
+}
+
+// waitRedisConnections - wait until miniredis has n open connections
+func waitRedisConnections(t *testing.T, s *miniredis.Miniredis, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for s.CurrentConnectionCount() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d open connections, got %d", n, s.CurrentConnectionCount())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestInitRedisConnectionFailed(t *testing.T) {
+	s := miniredis.RunT(t)
+	cfg := miniredisConfig(s)
+	cfg.Conn.ConnTTL = 1
+	s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	client, err := initRedis(ctx, cfg)
+	if err == nil || client != nil {
+		t.Errorf("expected the error of the connection without client, got %v, %v", client, err)
+	}
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// replicaPools - connection pools of the read replicas, keyed by the
+// connection of the primary they are registered with
+var (
+	replicaPoolsMu sync.Mutex
+	replicaPools   = make(map[*gorm.DB][]*sql.DB)
+)
+
+// registerReplicas - connect to the read replicas of configureDB with
+// opts and register them with the dbresolver plugin
+//
+// SELECT queries are load-balanced randomly across the replicas, all
+// other queries and transactions are sent to the primary. The pools of
+// the replicas are closed by closeReplicas.
+func registerReplicas(ctx context.Context, db *gorm.DB, configureDB config.RDBMS, opts ...DBOption) error {
+	hosts := configureDB.Replica.Hosts
+	urls := configureDB.Replica.URLs
+	if len(hosts) == 0 && len(urls) == 0 {
+		return nil
+	}
+
+	replicaConfigs := make([]config.RDBMS, 0, len(hosts)+len(urls))
+	for _, host := range hosts {
+		replicaConfig, err := replicaHostConfig(configureDB, host)
+		if err != nil {
+			return err
+		}
+		replicaConfigs = append(replicaConfigs, replicaConfig)
+	}
+	for _, connURL := range urls {
+		replicaConfig := configureDB
+		replicaConfig.Access.URL = connURL
+		replicaConfigs = append(replicaConfigs, replicaConfig)
+	}
+
+	pools := make([]*sql.DB, 0, len(replicaConfigs))
+	closePools := func() {
+		for _, pool := range pools {
+			_ = pool.Close()
+		}
+	}
+
+	dialectors := make([]gorm.Dialector, 0, len(replicaConfigs))
+	for i, replicaConfig := range replicaConfigs {
+		// a replica does not have replicas of its own
+		replicaConfig.Replica.Hosts = nil
+		replicaConfig.Replica.URLs = nil
+
+		replica, err := openDB(ctx, replicaConfig, opts...)
+		if err != nil {
+			closePools()
+			return fmt.Errorf("replica %d: %w", i+1, err)
+		}
+		pool, err := replica.DB()
+		if err != nil {
+			closePools()
+			return fmt.Errorf("replica %d: %w", i+1, err)
+		}
+		pools = append(pools, pool)
+		// the dialector wraps the connection pool of the replica
+		dialectors = append(dialectors, replica.Dialector)
+	}
+
+	err := db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   dbresolver.RandomPolicy{},
+	}))
+	if err != nil {
+		closePools()
+		return fmt.Errorf("error code: 162: %w", err)
+	}
+
+	replicaPoolsMu.Lock()
+	replicaPools[db] = pools
+	replicaPoolsMu.Unlock()
+
+	return nil
+}
+
+// closeReplicas - close the pools of the replicas registered with db,
+// nothing to do without replicas
+func closeReplicas(db *gorm.DB) error {
+	replicaPoolsMu.Lock()
+	pools := replicaPools[db]
+	delete(replicaPools, db)
+	replicaPoolsMu.Unlock()
+
+	var errs []error
+	for _, pool := range pools {
+		if err := pool.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// closePool - close the connection pool of db and the pools of its
+// replicas
+func closePool(db *gorm.DB) error {
+	errReplicas := closeReplicas(db)
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to get connection pool: %w", err), errReplicas)
+	}
+	if err := sqlDB.Close(); err != nil {
+		return errors.Join(fmt.Errorf("failed to close connection pool: %w", err), errReplicas)
+	}
+	if errReplicas != nil {
+		return fmt.Errorf("failed to close the replica pools: %w", errReplicas)
+	}
+
+	return nil
+}
+
+// replicaHostConfig - copy configureDB and replace the address of the
+// primary with the address of a replica given as host or host:port
+func replicaHostConfig(configureDB config.RDBMS, address string) (config.RDBMS, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		// no port, keep the port of the primary
+		host = address
+		port = configureDB.Env.Port
+	}
+	if host == "" {
+		return configureDB, errors.New("invalid replica address " + address)
+	}
+
+	configureDB.Env.Host = host
+	configureDB.Env.Port = port
+
+	if configureDB.Access.URL != "" {
+		// do not wrap the parser error, it contains the full URL with the password
+		u, err := url.Parse(configureDB.Access.URL)
+		if err != nil {
+			return configureDB, errors.New("invalid connection URL")
+		}
+		if port == "" {
+			port = u.Port()
+		}
+		u.Host = host
+		if port != "" {
+			u.Host = net.JoinHostPort(host, port)
+		}
+		configureDB.Access.URL = u.String()
+	}
+
+	return configureDB, nil
+}
+
+// UsePrimary - route all queries of db to the primary
+//
+// Use it to read data right after writing it, when the replicas may
+// not have caught up yet. Without replicas, db is used as is.
+//
+//	database.UsePrimary(db).First(&user, id)
+func UsePrimary(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Write)
+}
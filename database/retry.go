@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// connectWithRetry - call connect until it succeeds or maxRetries
+// is exhausted
+//
+// The delay between two attempts starts at retryDelay and is doubled
+// after each failed attempt, but it never exceeds retryMaxDelay. No
+// further attempt is made once ctx is done.
+func connectWithRetry(ctx context.Context, name string, maxRetries int, retryDelay, retryMaxDelay time.Duration, connect func() error) (err error) {
+	delay := retryDelay
+
+	for attempt := 1; ; attempt++ {
+		err = connect()
+		if err == nil || attempt > maxRetries {
+			return
+		}
+		if ctx.Err() != nil {
+			return errors.Join(err, ctx.Err())
+		}
+
+		log.WithError(err).WithFields(log.Fields{
+			"backend":    name,
+			"attempt":    attempt,
+			"maxRetries": maxRetries,
+			"retryIn":    delay.String(),
+		}).Warn("database connection failed, retrying")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if retryMaxDelay > 0 && delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+	}
+}
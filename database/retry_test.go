@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConnectWithRetryContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	errConnect := errors.New("connection refused")
+	attempts := 0
+
+	start := time.Now()
+	err := connectWithRetry(ctx, "test", 10, time.Second, time.Second, func() error {
+		attempts++
+		return errConnect
+	})
+
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("retries did not stop at the deadline of ctx")
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+	if !errors.Is(err, errConnect) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the last error and the context error, got %v", err)
+	}
+}
+
+func TestConnectWithRetry(t *testing.T) {
+	attempts := 0
+	err := connectWithRetry(context.Background(), "test", 3, time.Millisecond, time.Millisecond, func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	sqldriver "database/sql/driver"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// WithSchema - session of the default connection whose queries run
+// with search_path set to schema, postgres only, nil when the
+// database is not initialized
+//
+// The session reserves one connection of the pool until ctx is done,
+// then the search_path is reset and the connection is returned to the
+// pool. Pass a context which ends, e.g. the context of the request:
+//
+//	db := database.WithSchema(c.Request.Context(), tenant.Schema)
+//	err := db.Find(&orders).Error
+//
+// Transactions started from the session run on the same connection.
+// The schema must be an unquoted identifier, other names are rejected
+// with the error of the session. Sessions are not supported with read
+// replicas, the replicas would run the queries with the default
+// search_path.
+func WithSchema(ctx context.Context, schema string) *gorm.DB {
+	db := GetDB()
+	if db == nil {
+		return nil
+	}
+
+	db = db.WithContext(ctx)
+	if db.Dialector.Name() != DriverPostgres {
+		_ = db.AddError(errors.New("database: WithSchema is only supported by postgres"))
+		return db
+	}
+	if _, ok := db.Config.Plugins[(&dbresolver.DBResolver{}).Name()]; ok {
+		_ = db.AddError(errors.New("database: WithSchema is not supported with read replicas"))
+		return db
+	}
+	if !validSchemaName(schema) {
+		_ = db.AddError(errors.New("database: invalid schema name " + schema))
+		return db
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		_ = db.AddError(err)
+		return db
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		_ = db.AddError(err)
+		return db
+	}
+	// the name is validated, identifiers cannot be bound as parameters
+	if _, err := conn.ExecContext(ctx, "SET search_path TO "+schema); err != nil {
+		_ = conn.Close()
+		_ = db.AddError(err)
+		return db
+	}
+	context.AfterFunc(ctx, func() {
+		if _, err := conn.ExecContext(context.Background(), "RESET search_path"); err != nil {
+			// discard the connection instead of returning it to the
+			// pool with the schema of the session
+			_ = conn.Raw(func(interface{}) error { return sqldriver.ErrBadConn })
+		}
+		_ = conn.Close()
+	})
+
+	tx := db.Session(&gorm.Session{})
+	tx.Statement.ConnPool = conn
+
+	return tx
+}
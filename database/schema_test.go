@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestWithSchema(t *testing.T) {
+	_ = CloseDB()
+	if db := WithSchema(context.Background(), "tenant_a"); db != nil {
+		t.Error("expected nil without database")
+	}
+
+	if _, err := InitTestDB(); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+
+	db := WithSchema(context.Background(), "tenant_a")
+	if db == nil {
+		t.Fatal("expected a session")
+	}
+	if db.Error == nil || !strings.Contains(db.Error.Error(), "only supported by postgres") {
+		t.Errorf("expected an unsupported driver error, got %v", db.Error)
+	}
+	// the error is kept by the chained queries
+	var n int64
+	if err := db.Table("tenants").Count(&n).Error; err == nil {
+		t.Error("expected the error of the session")
+	}
+}
+
+// testWithSchema - two tenants see their own rows only, postgres only
+func testWithSchema(t *testing.T, db *gorm.DB) {
+	for _, schema := range []string{"tenant_a", "tenant_b"} {
+		if err := db.Exec("CREATE SCHEMA IF NOT EXISTS " + schema).Error; err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+		if err := db.Exec("CREATE TABLE IF NOT EXISTS " + schema + ".integration_items (id serial PRIMARY KEY, name text)").Error; err != nil {
+			t.Fatalf("failed to create table: %v", err)
+		}
+	}
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	defer cancelA()
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	tenantA := WithSchema(ctxA, "tenant_a")
+	tenantB := WithSchema(ctxB, "tenant_b")
+	if tenantA.Error != nil || tenantB.Error != nil {
+		t.Fatalf("failed to switch schema: %v, %v", tenantA.Error, tenantB.Error)
+	}
+
+	if err := tenantA.Create(&integrationItem{Name: "a"}).Error; err != nil {
+		t.Fatalf("failed to create: %v", err)
+	}
+	err := tenantB.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&[]integrationItem{{Name: "b1"}, {Name: "b2"}}).Error
+	})
+	if err != nil {
+		t.Fatalf("failed to create: %v", err)
+	}
+
+	var countA, countB int64
+	tenantA.Model(&integrationItem{}).Count(&countA)
+	tenantB.Model(&integrationItem{}).Count(&countB)
+	if countA != 1 || countB != 2 {
+		t.Errorf("expected 1 and 2 rows, got %d and %d", countA, countB)
+	}
+
+	if WithSchema(ctxA, "tenant_a; DROP TABLE users").Error == nil {
+		t.Error("expected an invalid schema error")
+	}
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CaseInsensitiveCollation - case-insensitive collation of the mysql
+// comparisons of CaseInsensitiveLike, it must belong to the character
+// set of the columns
+var CaseInsensitiveCollation = "utf8mb4_unicode_ci"
+
+// CaseInsensitiveLike - add a case-insensitive LIKE condition on column
+// to db, pattern uses the wildcards % and _
+//
+// - postgres, clickhouse: column ILIKE pattern
+//
+// - mysql: column COLLATE CaseInsensitiveCollation LIKE pattern, so
+// that it also applies to columns with a case-sensitive or binary
+// collation
+//
+// - sqlite3, sqlserver: column LIKE pattern, case-insensitive by
+// default (ASCII only for sqlite3)
+//
+//	db := database.CaseInsensitiveLike(database.GetDB(), "name", "%"+term+"%")
+//	err := db.Find(&users).Error
+func CaseInsensitiveLike(db *gorm.DB, column, pattern string) *gorm.DB {
+	sql := "? LIKE ?"
+	switch db.Dialector.Name() {
+	case DriverPostgres, DriverClickHouse:
+		sql = "? ILIKE ?"
+	case DriverMySQL:
+		sql = "? COLLATE " + CaseInsensitiveCollation + " LIKE ?"
+	}
+
+	return db.Where(clause.Expr{SQL: sql, Vars: []interface{}{clause.Column{Name: column}, pattern}})
+}
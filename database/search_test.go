@@ -0,0 +1,82 @@
+package database
+
+import (
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type searchItem struct {
+	ID   uint
+	Name string
+}
+
+// testCaseInsensitiveLike - search of CaseInsensitiveLike on db
+func testCaseInsensitiveLike(t *testing.T, db *gorm.DB) {
+	if err := db.Migrator().DropTable(&searchItem{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&searchItem{}); err != nil {
+		t.Fatal(err)
+	}
+	items := []searchItem{{Name: "Gopher"}, {Name: "GOPHERCON"}, {Name: "rustacean"}}
+	if err := db.Create(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var found []searchItem
+	if err := CaseInsensitiveLike(db, "name", "gopher%").Order("id").Find(&found).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[0].Name != "Gopher" || found[1].Name != "GOPHERCON" {
+		t.Errorf("unexpected result %+v", found)
+	}
+}
+
+func TestCaseInsensitiveLike(t *testing.T) {
+	db, err := InitTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+
+	testCaseInsensitiveLike(t, db)
+}
+
+func TestCaseInsensitiveLikeSQL(t *testing.T) {
+	testCases := []struct {
+		name      string
+		dialector gorm.Dialector
+		want      string
+	}{
+		{
+			name:      "postgres",
+			dialector: postgres.New(postgres.Config{DSN: "host=localhost"}),
+			want:      `SELECT * FROM "search_items" WHERE "name" ILIKE $1`,
+		},
+		{
+			name:      "mysql",
+			dialector: mysql.New(mysql.Config{DSN: "user@tcp(localhost)/app", SkipInitializeWithVersion: true}),
+			want:      "SELECT * FROM `search_items` WHERE `name` COLLATE utf8mb4_unicode_ci LIKE ?",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := gorm.Open(tc.dialector, &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stmt := CaseInsensitiveLike(db, "name", "gopher%").Find(&[]searchItem{}).Statement
+			if got := stmt.SQL.String(); got != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+			if len(stmt.Vars) != 1 || stmt.Vars[0] != "gopher%" {
+				t.Errorf("unexpected vars %v", stmt.Vars)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// SeedMarker - marker of a seed applied by Seed
+type SeedMarker struct {
+	ID       uint      `gorm:"primaryKey"`
+	Marker   string    `gorm:"size:255;uniqueIndex"`
+	SeededAt time.Time `gorm:"autoCreateTime:false"`
+}
+
+// TableName - name of the table of the seed markers
+func (SeedMarker) TableName() string {
+	return "seed_markers"
+}
+
+// Seed - run fn once per database, e.g. to insert the baseline data of
+// a demo or a local environment
+//
+// fn runs in a transaction of the default connection with a row of
+// the marker recorded in the same transaction. When the marker already
+// exists, fn is not called. When fn returns an error or panics, the
+// partial seed is rolled back and Seed runs again on the next start.
+// A concurrent run of the same seed fails on the unique marker.
+//
+//	err := database.Seed(ctx, func(tx *gorm.DB) error {
+//		return tx.Create(&demoUsers).Error
+//	}, "demo-users-v1")
+func Seed(ctx context.Context, fn func(db *gorm.DB) error, marker string) error {
+	if strings.TrimSpace(marker) == "" {
+		return errors.New("marker is required")
+	}
+
+	db := GetDB()
+	if db == nil {
+		return ErrDBNotInitialized
+	}
+
+	if err := db.WithContext(ctx).AutoMigrate(&SeedMarker{}); err != nil {
+		return err
+	}
+
+	seeded := false
+	err := WithTransaction(ctx, func(tx *gorm.DB) error {
+		result := tx.Where("marker = ?", marker).Limit(1).Find(&SeedMarker{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			return nil
+		}
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+		seeded = true
+
+		return tx.Create(&SeedMarker{Marker: marker, SeededAt: tx.NowFunc().UTC()}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if seeded {
+		log.WithField("marker", marker).Info("database seeded")
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestSeed(t *testing.T) {
+	db := initTxTestDB(t)
+	ctx := context.Background()
+
+	failed := errors.New("failed")
+	err := Seed(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(&txItem{Name: "partial"}).Error; err != nil {
+			return err
+		}
+		return failed
+	}, "items")
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+	if n := countTxItems(t, db); n != 0 {
+		t.Fatalf("expected the partial seed to be rolled back, got %d items", n)
+	}
+
+	calls := 0
+	seed := func(tx *gorm.DB) error {
+		calls++
+		return tx.Create(&[]txItem{{Name: "a"}, {Name: "b"}}).Error
+	}
+	for i := 0; i < 2; i++ {
+		if err := Seed(ctx, seed, "items"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected fn to run once, got %d", calls)
+	}
+	if n := countTxItems(t, db); n != 2 {
+		t.Errorf("expected 2 items, got %d", n)
+	}
+
+	if err := Seed(ctx, seed, " "); err == nil {
+		t.Error("expected an error for an empty marker")
+	}
+}
+
+func TestSeedNotInitialized(t *testing.T) {
+	_ = CloseDB()
+	err := Seed(context.Background(), func(*gorm.DB) error { return nil }, "items")
+	if !errors.Is(err, ErrDBNotInitialized) {
+		t.Errorf("expected ErrDBNotInitialized, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// serverVersionCache - version of the server of the default
+// connection, queried again after a reconnect
+var serverVersionCache struct {
+	mu      sync.Mutex
+	db      *gorm.DB
+	version string
+}
+
+// ServerVersion - version of the server of the default connection,
+// e.g. "8.0.36" for mysql or "16.2" for postgres
+//
+// The version is queried on the first call and cached until the
+// connection is replaced. Useful to enable version-dependent SQL, e.g.
+// the window functions of mysql 8.
+func ServerVersion(ctx context.Context) (string, error) {
+	db := GetDB()
+	if db == nil {
+		return "", ErrDBNotInitialized
+	}
+
+	serverVersionCache.mu.Lock()
+	defer serverVersionCache.mu.Unlock()
+	if serverVersionCache.db == db {
+		return serverVersionCache.version, nil
+	}
+
+	version, err := serverVersion(ctx, db)
+	if err != nil {
+		return "", err
+	}
+	serverVersionCache.db = db
+	serverVersionCache.version = version
+
+	return version, nil
+}
+
+// serverVersion - query the version of the server of db
+func serverVersion(ctx context.Context, db *gorm.DB) (string, error) {
+	var query string
+	switch db.Dialector.Name() {
+	case DriverMySQL, DriverClickHouse:
+		query = "SELECT version()"
+	case DriverPostgres:
+		if isCockroachDB(db) {
+			// "CockroachDB CCL v23.2.0 (...)", server_version is the
+			// version of postgres it is compatible with
+			query = "SELECT split_part(version(), ' ', 3)"
+			break
+		}
+		// version() also contains the platform and the compiler
+		query = "SHOW server_version"
+	case "sqlite":
+		query = "SELECT sqlite_version()"
+	case DriverSQLServer:
+		query = "SELECT CAST(SERVERPROPERTY('ProductVersion') AS nvarchar(128))"
+	default:
+		return "", errors.New("database: server version of " + db.Dialector.Name() + " is not supported")
+	}
+
+	var version string
+	if err := db.WithContext(ctx).Raw(query).Scan(&version).Error; err != nil {
+		return "", err
+	}
+
+	return version, nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestServerVersion(t *testing.T) {
+	_ = CloseDB()
+	if _, err := ServerVersion(context.Background()); !errors.Is(err, ErrDBNotInitialized) {
+		t.Fatalf("expected ErrDBNotInitialized, got %v", err)
+	}
+
+	db, err := InitTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+
+	version, err := ServerVersion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^3\.\d+\.\d+`).MatchString(version) {
+		t.Errorf("unexpected sqlite version %q", version)
+	}
+
+	// cached for the connection
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = sqlDB.Close()
+	if cached, err := ServerVersion(context.Background()); err != nil || cached != version {
+		t.Errorf("expected the cached version %q, got %q, %v", version, cached, err)
+	}
+
+	// queried again for a new connection
+	if _, err := InitTestDB(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ServerVersion(context.Background()); err != nil {
+		t.Errorf("expected the version of the new connection, got %v", err)
+	}
+}
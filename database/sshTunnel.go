@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// sshTunnels - tunnels shared by the connection pools, keyed by the
+// user, the SSH server and the key, so that a reconnect reuses the
+// SSH connection
+var (
+	sshTunnelsMu sync.Mutex
+	sshTunnels   = make(map[string]*sshTunnel)
+)
+
+// sshTunnel - SSH connection forwarding the connections to the
+// database, opened on the first dial and reopened after it dropped
+type sshTunnel struct {
+	addr   string
+	config *ssh.ClientConfig
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// getSSHTunnel - tunnel described by cfg, created on the first call
+//
+// The key of the SSH server is verified with the known_hosts file.
+func getSSHTunnel(cfg config.SSHTunnel) (*sshTunnel, error) {
+	addr := cfg.Host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+	if cfg.User == "" || cfg.KeyPath == "" {
+		return nil, errors.New("ssh tunnel: DBSSH_USER and DBSSH_KEY are required")
+	}
+
+	id := cfg.User + "@" + addr + "|" + cfg.KeyPath
+	sshTunnelsMu.Lock()
+	defer sshTunnelsMu.Unlock()
+	if t, ok := sshTunnels[id]; ok {
+		return t, nil
+	}
+
+	key, err := os.ReadFile(cfg.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("ssh tunnel: DBSSH_KEY: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("ssh tunnel: DBSSH_KEY: %w", err)
+	}
+
+	knownHostsPath := cfg.KnownHostsPath
+	if knownHostsPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("ssh tunnel: DBSSH_KNOWN_HOSTS: %w", err)
+		}
+		knownHostsPath = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsPath)
+	if err != nil {
+		return nil, fmt.Errorf("ssh tunnel: DBSSH_KNOWN_HOSTS: %w", err)
+	}
+
+	t := &sshTunnel{
+		addr: addr,
+		config: &ssh.ClientConfig{
+			User:            cfg.User,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeyCallback,
+		},
+	}
+	sshTunnels[id] = t
+
+	return t, nil
+}
+
+// DialContext - open a connection to addr forwarded by the SSH server,
+// the address is resolved by the SSH server
+func (t *sshTunnel) DialContext(ctx context.Context, _, addr string) (net.Conn, error) {
+	client, err := t.sshClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := client.DialContext(ctx, "tcp", addr)
+	if err != nil && t.dropIfClosed(client) {
+		// the SSH connection dropped, retry once with a new one
+		if client, err = t.sshClient(ctx); err != nil {
+			return nil, err
+		}
+		conn, err = client.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ssh tunnel: %w", err)
+	}
+
+	return conn, nil
+}
+
+// dropIfClosed - forget client when the SSH connection is closed
+func (t *sshTunnel) dropIfClosed(client *ssh.Client) bool {
+	if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err == nil {
+		return false
+	}
+
+	t.mu.Lock()
+	if t.client == client {
+		t.client = nil
+	}
+	t.mu.Unlock()
+
+	return true
+}
+
+// sshClient - the open SSH connection, opened when needed
+func (t *sshTunnel) sshClient(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return nil, fmt.Errorf("ssh tunnel: %w", err)
+	}
+	// the handshake is not bound to ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, t.addr, t.config)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("ssh tunnel: %w", err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	client := ssh.NewClient(c, chans, reqs)
+	t.client = client
+	go func() {
+		// reopened on the next dial
+		_ = client.Wait()
+		t.dropIfClosed(client)
+	}()
+
+	return client, nil
+}
+
+// lookupRemote - resolver of pgx leaving the host as is, so that it
+// is resolved by the SSH server
+func lookupRemote(_ context.Context, host string) ([]string, error) {
+	return []string{host}, nil
+}
@@ -0,0 +1,231 @@
+package database
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// startSSHServer - SSH server forwarding the direct-tcpip channels,
+// accepting the returned client key and trusted by the returned
+// known_hosts file
+func startSSHServer(t *testing.T) (addr, keyPath, knownHostsPath string) {
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientPub, clientKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorized, err := ssh.NewPublicKey(clientPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(authorized.Marshal()) {
+				return nil, io.EOF
+			}
+			return nil, nil
+		},
+	}
+	serverConfig.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSH(conn, serverConfig)
+		}
+	}()
+
+	dir := t.TempDir()
+	block, err := ssh.MarshalPrivateKey(clientKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath = filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	addr = ln.Addr().String()
+	knownHostsPath = filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostSigner.PublicKey())
+	if err := os.WriteFile(knownHostsPath, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return addr, keyPath, knownHostsPath
+}
+
+// serveSSH - forward the direct-tcpip channels of conn
+func serveSSH(conn net.Conn, serverConfig *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, serverConfig)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		var target struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &target); err != nil {
+			_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		upstream, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+		if err != nil {
+			_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, channelReqs, err := newChannel.Accept()
+		if err != nil {
+			_ = upstream.Close()
+			continue
+		}
+		go ssh.DiscardRequests(channelReqs)
+		go func() {
+			_, _ = io.Copy(channel, upstream)
+			_ = channel.Close()
+		}()
+		go func() {
+			_, _ = io.Copy(upstream, channel)
+			_ = upstream.Close()
+		}()
+	}
+}
+
+func TestSSHTunnel(t *testing.T) {
+	addr, keyPath, knownHostsPath := startSSHServer(t)
+
+	// echo server reachable through the tunnel
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(conn, conn)
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	cfg := config.SSHTunnel{Host: addr, User: "gorest", KeyPath: keyPath, KnownHostsPath: knownHostsPath}
+	tunnel, err := getSSHTunnel(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := getSSHTunnel(cfg); err != nil || again != tunnel {
+		t.Errorf("expected the tunnel to be reused, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		conn, err := tunnel.DialContext(context.Background(), "tcp", echo.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.Write([]byte("ping")); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 4)
+		if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+			t.Errorf("expected ping, got %q: %v", buf, err)
+		}
+		_ = conn.Close()
+
+		// dropped SSH connection, reopened on the next dial
+		tunnel.mu.Lock()
+		client := tunnel.client
+		tunnel.mu.Unlock()
+		_ = client.Close()
+		_ = client.Wait()
+	}
+}
+
+func TestSSHTunnelErrors(t *testing.T) {
+	addr, keyPath, _ := startSSHServer(t)
+	_, _, otherKnownHosts := startSSHServer(t)
+	dir := t.TempDir()
+	invalidKey := filepath.Join(dir, "invalid")
+	if err := os.WriteFile(invalidKey, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name string
+		cfg  config.SSHTunnel
+		want string
+	}{
+		{name: "missing user", cfg: config.SSHTunnel{Host: addr, KeyPath: keyPath}, want: "DBSSH_USER"},
+		{name: "missing key", cfg: config.SSHTunnel{Host: addr, User: "gorest", KeyPath: filepath.Join(dir, "missing")}, want: "DBSSH_KEY"},
+		{name: "invalid key", cfg: config.SSHTunnel{Host: addr, User: "gorest", KeyPath: invalidKey}, want: "DBSSH_KEY"},
+		{name: "missing known hosts", cfg: config.SSHTunnel{Host: addr, User: "gorest", KeyPath: keyPath, KnownHostsPath: filepath.Join(dir, "missing")}, want: "DBSSH_KNOWN_HOSTS"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := getSSHTunnel(tc.cfg); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected an error about %s, got %v", tc.want, err)
+			}
+		})
+	}
+
+	// unknown host key
+	tunnel, err := getSSHTunnel(config.SSHTunnel{Host: addr, User: "other", KeyPath: keyPath, KnownHostsPath: otherKnownHosts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tunnel.DialContext(context.Background(), "tcp", "127.0.0.1:1"); err == nil || !strings.Contains(err.Error(), "key") {
+		t.Errorf("expected a host key error, got %v", err)
+	}
+
+	configureDB := config.RDBMS{}
+	configureDB.Env.Driver = DriverSQLite
+	configureDB.Access.DbName = filepath.Join(dir, "app.db")
+	configureDB.Env.SSHTunnel.Host = addr
+	if _, err := openDB(context.Background(), configureDB); err == nil || !strings.Contains(err.Error(), "error code: 168") {
+		t.Errorf("expected error code 168, got %v", err)
+	}
+}
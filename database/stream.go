@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StreamRows - run query and call fn for each row of the result, so
+// that large results, e.g. of an export, are not loaded in memory
+//
+// scan reads the current row into dest like sql.Rows.Scan, one
+// destination per column. A single pointer to a struct (a model) or a
+// map[string]interface{} is filled by GORM with the columns matching
+// its fields.
+//
+// The iteration stops at the first error of fn, which is returned. The
+// rows are closed before StreamRows returns, the connection is held
+// until then.
+//
+//	err := database.StreamRows(ctx, db.Model(&model.User{}).Where("active = ?", true),
+//		func(scan func(dest ...interface{}) error) error {
+//			var user model.User
+//			if err := scan(&user); err != nil {
+//				return err
+//			}
+//			return w.Write(user.CSV())
+//		})
+func StreamRows(ctx context.Context, query *gorm.DB, fn func(scan func(dest ...interface{}) error) error) error {
+	if query == nil {
+		return ErrDBNotInitialized
+	}
+
+	db := query.WithContext(ctx)
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	scan := func(dest ...interface{}) error {
+		if len(dest) == 1 && scannedByGORM(dest[0]) {
+			return db.ScanRows(rows, dest[0])
+		}
+		return rows.Scan(dest...)
+	}
+	for rows.Next() {
+		if err := fn(scan); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// scannedByGORM - whether dest is a struct or a map filled by the
+// column mapping of GORM
+//
+// The basic types are read by GORM until the last row, they are
+// scanned with sql.Rows.Scan instead.
+func scannedByGORM(dest interface{}) bool {
+	switch dest.(type) {
+	case map[string]interface{}, *map[string]interface{}:
+		return true
+	case sql.Scanner, *time.Time:
+		return false
+	}
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr {
+		return false
+	}
+
+	return v.Type().Elem().Kind() == reflect.Struct
+}
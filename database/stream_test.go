@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestStreamRows(t *testing.T) {
+	if err := StreamRows(context.Background(), nil, nil); !errors.Is(err, ErrDBNotInitialized) {
+		t.Errorf("expected ErrDBNotInitialized, got %v", err)
+	}
+
+	db := initTxTestDB(t)
+	items := make([]txItem, 1000)
+	for i := range items {
+		items[i].Name = fmt.Sprintf("item-%d", i)
+	}
+	if err := db.CreateInBatches(&items, 200).Error; err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// model
+	n := 0
+	err = StreamRows(context.Background(), db.Model(&txItem{}).Order("id"), func(scan func(dest ...interface{}) error) error {
+		var item txItem
+		if err := scan(&item); err != nil {
+			return err
+		}
+		if want := fmt.Sprintf("item-%d", n); item.Name != want {
+			return fmt.Errorf("expected %s, got %s", want, item.Name)
+		}
+		n++
+		return nil
+	})
+	if err != nil || n != len(items) {
+		t.Errorf("expected %d rows, got %d: %v", len(items), n, err)
+	}
+
+	// columns
+	n = 0
+	err = StreamRows(context.Background(), db.Model(&txItem{}).Select("id", "name"), func(scan func(dest ...interface{}) error) error {
+		var id uint
+		var name string
+		n++
+		return scan(&id, &name)
+	})
+	if err != nil || n != len(items) {
+		t.Errorf("expected %d rows, got %d: %v", len(items), n, err)
+	}
+
+	// single column
+	n = 0
+	err = StreamRows(context.Background(), db.Model(&txItem{}).Select("name"), func(scan func(dest ...interface{}) error) error {
+		var name string
+		n++
+		return scan(&name)
+	})
+	if err != nil || n != len(items) {
+		t.Errorf("expected %d rows, got %d: %v", len(items), n, err)
+	}
+
+	// map
+	err = StreamRows(context.Background(), db.Model(&txItem{}).Limit(1), func(scan func(dest ...interface{}) error) error {
+		row := map[string]interface{}{}
+		if err := scan(&row); err != nil {
+			return err
+		}
+		if row["name"] != "item-0" {
+			return fmt.Errorf("unexpected row %v", row)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	// early return
+	n = 0
+	errStop := errors.New("stop")
+	err = StreamRows(context.Background(), db.Model(&txItem{}), func(func(dest ...interface{}) error) error {
+		n++
+		if n == 10 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || n != 10 {
+		t.Errorf("expected the error of fn after 10 rows, got %d: %v", n, err)
+	}
+	if inUse := sqlDB.Stats().InUse; inUse != 0 {
+		t.Errorf("expected the rows to be closed, %d connections in use", inUse)
+	}
+
+	// query error
+	err = StreamRows(context.Background(), db.Table("missing_table"), func(func(dest ...interface{}) error) error {
+		return nil
+	})
+	if err == nil {
+		t.Error("expected the error of the query")
+	}
+
+	// cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = StreamRows(ctx, db.Model(&txItem{}), func(func(dest ...interface{}) error) error {
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if inUse := sqlDB.Stats().InUse; inUse != 0 {
+		t.Errorf("expected the rows to be closed, %d connections in use", inUse)
+	}
+}
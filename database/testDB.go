@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// testDBCount - number of databases created by InitTestDB
+var testDBCount atomic.Int64
+
+// InitTestDB - initialize an in-memory sqlite database for tests
+//
+// Each call creates a new empty database with foreign keys enabled and
+// registers it as the default connection, so GetDB returns it. The
+// database is shared by all connections of the pool and dropped by
+// CloseDB. The options are applied on top of the sqlite config, e.g.
+// WithNowFunc to freeze the timestamps.
+func InitTestDB(opts ...DBOption) (*gorm.DB, error) {
+	cfg := config.RDBMS{}
+	cfg.Env.Driver = DriverSQLite
+	cfg.Access.DbName = fmt.Sprintf("file:gorest_test_%d?mode=memory&cache=shared&_foreign_keys=1", testDBCount.Add(1))
+
+	db, err := openDB(context.Background(), cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	dbRegistryMu.Lock()
+	previous := dbClient
+	dbClient = db
+	dbRegistry[DefaultDBName] = db
+	dbRegistryMu.Unlock()
+
+	if previous != nil {
+		_ = closePool(previous)
+	}
+
+	return db, nil
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pilinux/gorest/config"
+)
+
+func TestInitTestDB(t *testing.T) {
+	db, err := InitTestDB()
+	if err != nil {
+		t.Fatalf("failed to init test db: %v", err)
+	}
+	defer func() {
+		_ = CloseDB()
+	}()
+
+	if GetDB() != db {
+		t.Error("GetDB must return the test database")
+	}
+
+	statements := []string{
+		"CREATE TABLE parents (id INTEGER PRIMARY KEY)",
+		"CREATE TABLE children (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parents(id))",
+		"INSERT INTO parents (id) VALUES (1)",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+
+	// the schema is visible to all connections of the pool
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var count int64
+			errs <- db.Table("parents").Count(&count).Error
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("query on another connection failed: %v", err)
+		}
+	}
+
+	if err := db.Exec("INSERT INTO children (id, parent_id) VALUES (1, 2)").Error; err == nil {
+		t.Error("expected a foreign key violation")
+	}
+
+	// a second test database starts empty
+	other, err := InitTestDB()
+	if err != nil {
+		t.Fatalf("failed to init second test db: %v", err)
+	}
+	if other.Migrator().HasTable("parents") {
+		t.Error("test databases must not share their schema")
+	}
+}
+
+func TestSQLiteMemory(t *testing.T) {
+	cfg := config.RDBMS{}
+	cfg.Env.Driver = "sqlite3"
+	cfg.Access.DbName = ":memory:"
+	cfg.Conn.MaxOpenConns = 10
+
+	db, err := openDB(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	if err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY)").Error; err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if !db.Migrator().HasTable("items") {
+			t.Fatal("the in-memory database was dropped between statements")
+		}
+	}
+}
+
+func TestGetSQLDB(t *testing.T) {
+	_ = CloseDB()
+	if _, err := GetSQLDB(); !errors.Is(err, ErrDBNotInitialized) {
+		t.Errorf("expected ErrDBNotInitialized, got %v", err)
+	}
+
+	if _, err := InitTestDB(); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+
+	sqlDB, err := GetSQLDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sqlDB.Ping(); err != nil {
+		t.Error(err)
+	}
+}
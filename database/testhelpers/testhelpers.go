@@ -0,0 +1,277 @@
+// Package testhelpers starts ephemeral database containers with
+// testcontainers-go for integration tests
+//
+// Each helper returns the config of the started database, ready to be
+// passed to the init functions of the database package. The
+// containers are terminated with t.Cleanup. When Docker is not
+// available or the tests run with -short, the test is skipped.
+package testhelpers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/pilinux/gorest/config"
+)
+
+// Images of the containers, override them to test other versions
+var (
+	MySQLImage       = "mysql:8.0"
+	PostgresImage    = "postgres:16-alpine"
+	RedisImage       = "redis:7-alpine"
+	MongoImage       = "mongo:7"
+	CockroachDBImage = "cockroachdb/cockroach:v23.2.4"
+)
+
+// credentials of the test databases
+const (
+	testUser   = "gorest"
+	testPass   = "gorest-pass"
+	testDBName = "gorest"
+)
+
+// Containers - configs of all test databases
+type Containers struct {
+	MySQL    config.RDBMS
+	Postgres config.RDBMS
+	Redis    config.REDIS
+	MongoDB  config.MongoDB
+}
+
+// PrepareTestContainers - start MySQL, Postgres, Redis and MongoDB
+func PrepareTestContainers(t *testing.T) Containers {
+	t.Helper()
+
+	return Containers{
+		MySQL:    MySQL(t),
+		Postgres: Postgres(t),
+		Redis:    Redis(t),
+		MongoDB:  MongoDB(t),
+	}
+}
+
+// MySQL - start a MySQL container
+func MySQL(t *testing.T) config.RDBMS {
+	t.Helper()
+
+	host, port := start(t, testcontainers.ContainerRequest{
+		Image:        MySQLImage,
+		ExposedPorts: []string{"3306/tcp"},
+		Env: map[string]string{
+			"MYSQL_ROOT_PASSWORD": testPass,
+			"MYSQL_USER":          testUser,
+			"MYSQL_PASSWORD":      testPass,
+			"MYSQL_DATABASE":      testDBName,
+		},
+		WaitingFor: wait.ForAll(
+			wait.ForLog("port: 3306  MySQL Community Server"),
+			wait.ForListeningPort("3306/tcp"),
+		).WithDeadline(3 * time.Minute),
+	}, "3306/tcp")
+
+	return rdbms("mysql", host, port)
+}
+
+// Postgres - start a Postgres container
+func Postgres(t *testing.T) config.RDBMS {
+	t.Helper()
+
+	host, port := start(t, testcontainers.ContainerRequest{
+		Image:        PostgresImage,
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_USER":     testUser,
+			"POSTGRES_PASSWORD": testPass,
+			"POSTGRES_DB":       testDBName,
+		},
+		// the server restarts once after the initialization
+		WaitingFor: wait.ForAll(
+			wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+			wait.ForListeningPort("5432/tcp"),
+		).WithDeadline(time.Minute),
+	}, "5432/tcp")
+
+	return rdbms("postgres", host, port)
+}
+
+// CockroachDB - start a single node CockroachDB container in insecure
+// mode
+func CockroachDB(t *testing.T) config.RDBMS {
+	t.Helper()
+
+	host, port := start(t, testcontainers.ContainerRequest{
+		Image:        CockroachDBImage,
+		ExposedPorts: []string{"26257/tcp", "8080/tcp"},
+		Cmd:          []string{"start-single-node", "--insecure"},
+		Env: map[string]string{
+			"COCKROACH_DATABASE": testDBName,
+		},
+		WaitingFor: wait.ForAll(
+			wait.ForHTTP("/health?ready=1").WithPort("8080/tcp"),
+			wait.ForListeningPort("26257/tcp"),
+		).WithDeadline(2 * time.Minute),
+	}, "26257/tcp")
+
+	// the insecure mode only accepts root without a password
+	configureDB := rdbms("cockroachdb", host, port)
+	configureDB.Access.User = "root"
+	configureDB.Access.Pass = ""
+
+	return configureDB
+}
+
+// Redis - start a Redis container
+func Redis(t *testing.T) config.REDIS {
+	t.Helper()
+
+	host, port := start(t, testcontainers.ContainerRequest{
+		Image:        RedisImage,
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForLog("Ready to accept connections").WithStartupTimeout(time.Minute),
+	}, "6379/tcp")
+
+	configureRedis := config.REDIS{Activate: config.Activated}
+	configureRedis.Env.Host = host
+	configureRedis.Env.Port = port
+	configureRedis.Conn.PoolSize = 2
+	configureRedis.Conn.ConnTTL = 10
+
+	return configureRedis
+}
+
+// MongoDB - start a MongoDB container
+func MongoDB(t *testing.T) config.MongoDB {
+	t.Helper()
+
+	host, port := start(t, testcontainers.ContainerRequest{
+		Image:        MongoImage,
+		ExposedPorts: []string{"27017/tcp"},
+		WaitingFor: wait.ForAll(
+			wait.ForLog("Waiting for connections"),
+			wait.ForListeningPort("27017/tcp"),
+		).WithDeadline(time.Minute),
+	}, "27017/tcp")
+
+	return mongoDB(fmt.Sprintf("mongodb://%s:%s", host, port))
+}
+
+// MongoDBReplicaSet - start a MongoDB container running a single
+// member replica set, e.g. to test transactions
+func MongoDBReplicaSet(t *testing.T) config.MongoDB {
+	t.Helper()
+
+	// the member is reached with a direct connection, its address
+	// inside the container is not reachable from the host
+	initiate := `rs.initiate({_id: "rs0", members: [{_id: 0, host: "localhost:27017"}]});
+while (!db.hello().isWritablePrimary) { sleep(100); }`
+
+	host, port := start(t, testcontainers.ContainerRequest{
+		Image:        MongoImage,
+		ExposedPorts: []string{"27017/tcp"},
+		Cmd:          []string{"--replSet", "rs0", "--bind_ip_all"},
+		WaitingFor: wait.ForAll(
+			wait.ForLog("Waiting for connections"),
+			wait.ForListeningPort("27017/tcp"),
+		).WithDeadline(time.Minute),
+		LifecycleHooks: []testcontainers.ContainerLifecycleHooks{{
+			PostReadies: []testcontainers.ContainerHook{
+				func(ctx context.Context, c testcontainers.Container) error {
+					code, _, err := c.Exec(ctx, []string{"mongosh", "--quiet", "--eval", initiate})
+					if err != nil {
+						return err
+					}
+					if code != 0 {
+						return fmt.Errorf("rs.initiate exited with code %d", code)
+					}
+					return nil
+				},
+			},
+		}},
+	}, "27017/tcp")
+
+	return mongoDB(fmt.Sprintf("mongodb://%s:%s/?directConnection=true", host, port))
+}
+
+// mongoDB - config of a mongo test database
+func mongoDB(uri string) config.MongoDB {
+	configureMongo := config.MongoDB{Activate: config.Activated}
+	configureMongo.Env.AppName = "gorest-test"
+	configureMongo.Env.URI = uri
+	configureMongo.Env.DatabaseName = testDBName
+	configureMongo.Env.PoolSize = 10
+	configureMongo.Env.ConnTTL = 10
+	configureMongo.Env.MaxRetries = 3
+	configureMongo.Env.RetryDelay = time.Second
+	configureMongo.Env.RetryMaxDelay = 5 * time.Second
+	configureMongo.Env.EnableServerAPI = true
+
+	return configureMongo
+}
+
+// rdbms - config of a relational test database
+func rdbms(driver, host, port string) config.RDBMS {
+	configureDB := config.RDBMS{Activate: config.Activated}
+	configureDB.Env.Driver = driver
+	configureDB.Env.Host = host
+	configureDB.Env.Port = port
+	configureDB.Env.TimeZone = "UTC"
+	configureDB.Env.ParseTime = true
+	configureDB.Access.User = testUser
+	configureDB.Access.Pass = testPass
+	configureDB.Access.DbName = testDBName
+	configureDB.Ssl.Sslmode = "disable"
+	configureDB.Conn.MaxIdleConns = 2
+	configureDB.Conn.MaxOpenConns = 10
+	configureDB.Conn.ConnMaxLifetime = time.Hour
+	configureDB.Conn.MaxRetries = 5
+	configureDB.Conn.RetryDelay = time.Second
+	configureDB.Conn.RetryMaxDelay = 5 * time.Second
+	configureDB.Log.LogLevel = 1 // silent
+	configureDB.Log.SlowThreshold = config.DefaultSlowThreshold
+
+	return configureDB
+}
+
+// start - start the container, terminate it with t.Cleanup and return
+// the host and the mapped port
+func start(t *testing.T, req testcontainers.ContainerRequest, port string) (string, string) {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	ctx := context.Background()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if container != nil {
+		t.Cleanup(func() {
+			if err := container.Terminate(context.Background()); err != nil {
+				t.Logf("failed to terminate container %s: %v", req.Image, err)
+			}
+		})
+	}
+	if err != nil {
+		t.Fatalf("failed to start container %s: %v", req.Image, err)
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("failed to get the host of %s: %v", req.Image, err)
+	}
+	mappedPort, err := container.MappedPort(ctx, nat.Port(port))
+	if err != nil {
+		t.Fatalf("failed to get the port of %s: %v", req.Image, err)
+	}
+
+	return host, mappedPort.Port()
+}
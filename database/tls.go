@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"os"
 
 	"github.com/go-sql-driver/mysql"
@@ -84,8 +85,18 @@ import (
 // 7.5 convert PKCS#8 format key into PKCS#1 format
 //
 // `openssl rsa -in client-key.pem -out client-key.pem`
-func InitTLSMySQL() (err error) {
-	configureDB := config.GetConfig().Database.RDBMS
+func InitTLSMySQL() error {
+	tlsConfig, err := tlsConfigMySQL(config.GetConfig().Database.RDBMS)
+	if err != nil {
+		return err
+	}
+
+	return mysql.RegisterTLSConfig("custom", tlsConfig)
+}
+
+// tlsConfigMySQL - build the tls.Config for the certificates of
+// configureDB
+func tlsConfigMySQL(configureDB config.RDBMS) (tlsConfig *tls.Config, err error) {
 	minTLS := configureDB.Ssl.MinTLS
 	rootCA := configureDB.Ssl.RootCA
 	serverCert := configureDB.Ssl.ServerCert
@@ -117,7 +128,7 @@ func InitTLSMySQL() (err error) {
 		return
 	}
 
-	tlsConfig := tls.Config{}
+	tlsConfig = &tls.Config{}
 	tlsConfig.MinVersion = tls.VersionTLS12 // default: TLS 1.2
 
 	if minTLS == "1.1" {
@@ -137,14 +148,47 @@ func InitTLSMySQL() (err error) {
 
 		certs, err = tls.LoadX509KeyPair(clientCert, clientKey)
 		if err != nil {
-			return
+			return nil, err
 		}
 
 		clientCertificate = append(clientCertificate, certs)
 		tlsConfig.Certificates = clientCertificate
 	}
 
-	err = mysql.RegisterTLSConfig("custom", &tlsConfig)
-
 	return
 }
+
+// InitTLSRedis returns the tls.Config to connect to redis over TLS
+//
+// If REDIS_TLS_CA_CERT is set, the server certificate is verified
+// against this CA. Otherwise, the host machine's root CAs are used.
+func InitTLSRedis() (*tls.Config, error) {
+	return tlsConfigRedis(config.GetConfig().Database.REDIS)
+}
+
+// tlsConfigRedis - build the tls.Config for the certificates of
+// configureRedis
+func tlsConfigRedis(configureRedis config.REDIS) (*tls.Config, error) {
+	caCert := configureRedis.Conn.CACert
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// #nosec G402 -- only when explicitly requested in the config
+		InsecureSkipVerify: configureRedis.Conn.InsecureSkipVerify,
+	}
+
+	if caCert != "" {
+		pem, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA certificate: %w", err)
+		}
+
+		rootCertPool := x509.NewCertPool()
+		if ok := rootCertPool.AppendCertsFromPEM(pem); !ok {
+			return nil, errors.New("failed to parse PEM encoded certificates")
+		}
+		tlsConfig.RootCAs = rootCertPool
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracerName - instrumentation name of the spans of the SQL queries
+const tracerName string = "github.com/pilinux/gorest/database"
+
+// spanKey - key of the current span in the statement of a query
+const spanKey string = "gorest:otel_span"
+
+// registerTracing - create an OpenTelemetry span for each query of db
+//
+// The spans are children of the span in the context of the query,
+// use db.WithContext(c.Request.Context()) in the handlers to link
+// them to the trace of the incoming request. The tracer is taken from
+// the global TracerProvider, see otel.SetTracerProvider.
+//
+// Attributes: db.system, db.statement (with placeholders, the values
+// are not recorded), db.sql.table and db.rows_affected.
+func registerTracing(db *gorm.DB) error {
+	type processor struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}
+
+	processors := []processor{
+		{"create", db.Callback().Create().Before("gorm:create").Register, db.Callback().Create().After("gorm:create").Register},
+		{"query", db.Callback().Query().Before("gorm:query").Register, db.Callback().Query().After("gorm:query").Register},
+		{"update", db.Callback().Update().Before("gorm:update").Register, db.Callback().Update().After("gorm:update").Register},
+		{"delete", db.Callback().Delete().Before("gorm:delete").Register, db.Callback().Delete().After("gorm:delete").Register},
+		{"row", db.Callback().Row().Before("gorm:row").Register, db.Callback().Row().After("gorm:row").Register},
+		{"raw", db.Callback().Raw().Before("gorm:raw").Register, db.Callback().Raw().After("gorm:raw").Register},
+	}
+
+	for _, p := range processors {
+		if err := p.before("gorest:otel_before_"+p.operation, startSpan("gorm."+p.operation)); err != nil {
+			return err
+		}
+		if err := p.after("gorest:otel_after_"+p.operation, endSpan); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// startSpan - start a span before the query is executed
+func startSpan(spanName string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+
+		ctx, span := otel.Tracer(tracerName).Start(
+			db.Statement.Context,
+			spanName,
+			trace.WithSpanKind(trace.SpanKindClient),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+// endSpan - record the query and its result and end the span
+func endSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("db.system", db.Dialector.Name()),
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Statement.Table != "" {
+		span.SetAttributes(attribute.String("db.sql.table", db.Statement.Table))
+	}
+
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
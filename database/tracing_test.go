@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/pilinux/gorest/config"
+)
+
+type tracedItem struct {
+	ID   uint
+	Name string
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	cfg := config.RDBMS{}
+	cfg.Env.Driver = "sqlite3"
+	cfg.Access.DbName = filepath.Join(t.TempDir(), "tracing.db")
+	cfg.Log.EnableTracing = true
+
+	db, err := openDB(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := db.AutoMigrate(&tracedItem{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	if err := db.WithContext(ctx).Create(&tracedItem{Name: "secret"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	var span sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.Name() == "gorm.create" {
+			span = s
+		}
+	}
+	if span == nil {
+		t.Fatal("missing span of the create query")
+	}
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("the span must be a child of the span in ctx")
+	}
+
+	attributes := map[string]interface{}{}
+	for _, kv := range span.Attributes() {
+		attributes[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	if attributes["db.system"] != "sqlite" || attributes["db.sql.table"] != "traced_items" {
+		t.Errorf("unexpected attributes %v", attributes)
+	}
+	if attributes["db.rows_affected"] != int64(1) {
+		t.Errorf("expected 1 affected row, got %v", attributes["db.rows_affected"])
+	}
+	if statement, _ := attributes["db.statement"].(string); statement == "" || strings.Contains(statement, "secret") {
+		t.Errorf("unexpected statement %q", statement)
+	}
+}
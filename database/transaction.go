@@ -0,0 +1,249 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"time"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// ErrDBNotInitialized - the relational database has not been initialized
+var ErrDBNotInitialized = errors.New("database is not initialized")
+
+// TxOption - option of the transactions started by WithTransaction,
+// WithReadOnlyTransaction and WithRetryableTransaction
+type TxOption func(o *sql.TxOptions)
+
+// WithIsolationLevel - start the transaction with the given isolation
+// level instead of the default level of the server
+//
+// Supported levels:
+//
+// - postgres: LevelReadCommitted (default), LevelRepeatableRead,
+// LevelSerializable; LevelReadUncommitted behaves like read committed
+//
+// - mysql: LevelReadUncommitted, LevelReadCommitted,
+// LevelRepeatableRead (default), LevelSerializable
+//
+// - sqlserver: the levels of mysql, LevelSnapshot
+//
+// - sqlite3: transactions are always serializable, the level is
+// ignored
+//
+// - clickhouse: not supported
+//
+// The other levels fail when the transaction is started.
+//
+//	err := database.WithTransaction(ctx, fn, database.WithIsolationLevel(sql.LevelSerializable))
+func WithIsolationLevel(level sql.IsolationLevel) TxOption {
+	return func(o *sql.TxOptions) {
+		o.Isolation = level
+	}
+}
+
+// txOptions - base with opts applied, base itself without opts
+func txOptions(base *sql.TxOptions, opts []TxOption) *sql.TxOptions {
+	if len(opts) == 0 {
+		return base
+	}
+
+	o := sql.TxOptions{}
+	if base != nil {
+		o = *base
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &o
+}
+
+// WithTransaction - run fn in a transaction of the default connection
+//
+// The transaction is bound to ctx. It is committed when fn returns nil
+// and rolled back when fn returns an error or panics. After a panic,
+// the transaction is rolled back and the panic is propagated.
+//
+// With cockroachdb, the transaction is retried like
+// WithRetryableTransaction after a serialization failure, up to 5
+// attempts.
+//
+//	err := database.WithTransaction(c.Request.Context(), func(tx *gorm.DB) error {
+//		if err := tx.Create(&user).Error; err != nil {
+//			return err
+//		}
+//		return tx.Create(&auth).Error
+//	})
+func WithTransaction(ctx context.Context, fn func(tx *gorm.DB) error, opts ...TxOption) error {
+	db := GetDB()
+	if db == nil {
+		return ErrDBNotInitialized
+	}
+
+	return retryTransaction(ctx, db, 1, func() error {
+		return runTransaction(db.WithContext(ctx), txOptions(nil, opts), fn)
+	})
+}
+
+// WithReadOnlyTransaction - run fn in a read-only transaction of the
+// default connection, e.g. for reporting queries
+//
+// Any write inside the transaction fails: postgres and mysql start
+// the transaction with READ ONLY, sqlite3 enables PRAGMA query_only
+// for the duration of the transaction. sqlserver and clickhouse do not
+// support read-only transactions. With read replicas, the transaction
+// runs on a replica.
+//
+// The transaction is bound to ctx and handled like WithTransaction.
+func WithReadOnlyTransaction(ctx context.Context, fn func(tx *gorm.DB) error, opts ...TxOption) error {
+	db := GetDB()
+	if db == nil {
+		return ErrDBNotInitialized
+	}
+
+	if db.Dialector.Name() == "sqlite" {
+		// the driver ignores the read-only option
+		readOnlyFn := fn
+		fn = func(tx *gorm.DB) error {
+			if err := tx.Exec("PRAGMA query_only = ON").Error; err != nil {
+				return err
+			}
+			// the flag belongs to the connection, reset it before the
+			// connection returns to the pool, even if ctx is done
+			defer tx.WithContext(context.Background()).Exec("PRAGMA query_only = OFF")
+
+			return readOnlyFn(tx)
+		}
+	}
+
+	return retryTransaction(ctx, db, 1, func() error {
+		return runTransaction(db.WithContext(ctx).Clauses(dbresolver.Read), txOptions(&sql.TxOptions{ReadOnly: true}, opts), fn)
+	})
+}
+
+// delays between two attempts of WithRetryableTransaction
+const (
+	retryableTxDelay    = 10 * time.Millisecond
+	retryableTxMaxDelay = time.Second
+)
+
+// WithRetryableTransaction - run fn in a transaction like
+// WithTransaction, and run it again in a new transaction when it fails
+// with a deadlock or a serialization failure, up to attempts times
+//
+// The retried errors are the deadlocks of mysql (1213) and postgres
+// (40P01) and the serialization failures of postgres (40001), e.g. in
+// transactions started with
+// WithIsolationLevel(sql.LevelSerializable). The delay between two
+// attempts starts at 10ms with a random jitter and is doubled after
+// each attempt. The error of the last attempt is returned once
+// attempts are exhausted. fn must not have side effects outside of
+// the transaction, as it may run several times. With cockroachdb, at
+// least 5 attempts are made.
+//
+//	err := database.WithRetryableTransaction(ctx, 3, func(tx *gorm.DB) error {
+//		return tx.Model(&account).Update("balance", gorm.Expr("balance - ?", amount)).Error
+//	})
+func WithRetryableTransaction(ctx context.Context, attempts int, fn func(tx *gorm.DB) error, opts ...TxOption) error {
+	db := GetDB()
+	if db == nil {
+		return ErrDBNotInitialized
+	}
+
+	return retryTransaction(ctx, db, attempts, func() error {
+		return runTransaction(db.WithContext(ctx), txOptions(nil, opts), fn)
+	})
+}
+
+// retryTransaction - call run, which runs a transaction of db, up to
+// attempts times while it fails with a retryable error
+//
+// CockroachDB reports the conflicts of concurrent transactions as
+// serialization failures and expects the client to retry them, the
+// transactions of db are attempted at least cockroachTxAttempts times.
+func retryTransaction(ctx context.Context, db *gorm.DB, attempts int, run func() error) error {
+	if isCockroachDB(db) {
+		attempts = max(attempts, cockroachTxAttempts)
+	}
+	delay := retryableTxDelay
+
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if err == nil || attempt >= attempts || !isRetryableTxError(err) {
+			return err
+		}
+
+		wait := delay + time.Duration(rand.Int63n(int64(delay)))
+		log.WithError(err).WithFields(log.Fields{
+			"attempt":  attempt,
+			"attempts": attempts,
+			"retryIn":  wait.String(),
+		}).Debug("database: transaction conflict, retrying")
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+		delay = min(delay*2, retryableTxMaxDelay)
+	}
+}
+
+// isRetryableTxError - whether err is a deadlock or a serialization
+// failure, the transaction can succeed when it runs again
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// deadlock_detected, serialization_failure
+		return pgErr.Code == "40P01" || pgErr.Code == "40001"
+	}
+
+	var mysqlErr *gomysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// ER_LOCK_DEADLOCK
+		return mysqlErr.Number == 1213
+	}
+
+	return false
+}
+
+// runTransaction - run fn in a transaction of db started with opts
+func runTransaction(db *gorm.DB, opts *sql.TxOptions, fn func(tx *gorm.DB) error) (err error) {
+	tx := db.Begin(opts)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		// fn returned an error or called runtime.Goexit
+		tx.Rollback()
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return err
+	}
+	committed = true
+
+	return nil
+}
@@ -0,0 +1,273 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type txItem struct {
+	ID   uint
+	Name string
+}
+
+// initTxTestDB - in-memory database with an empty txItem table
+func initTxTestDB(t *testing.T) *gorm.DB {
+	db, err := InitTestDB()
+	if err != nil {
+		t.Fatalf("failed to init test db: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = CloseDB()
+	})
+
+	if err := db.AutoMigrate(&txItem{}); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// countTxItems - number of rows in the txItem table
+func countTxItems(t *testing.T, db *gorm.DB) int64 {
+	var count int64
+	if err := db.Model(&txItem{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return count
+}
+
+func TestWithTransactionCommit(t *testing.T) {
+	db := initTxTestDB(t)
+
+	err := WithTransaction(context.Background(), func(tx *gorm.DB) error {
+		return tx.Create(&txItem{Name: "committed"}).Error
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count := countTxItems(t, db); count != 1 {
+		t.Errorf("expected 1 row, got %d", count)
+	}
+}
+
+func TestWithTransactionErrorRollback(t *testing.T) {
+	db := initTxTestDB(t)
+	errFailed := errors.New("failed")
+
+	err := WithTransaction(context.Background(), func(tx *gorm.DB) error {
+		if err := tx.Create(&txItem{Name: "rolled back"}).Error; err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+	if count := countTxItems(t, db); count != 0 {
+		t.Errorf("expected 0 rows, got %d", count)
+	}
+}
+
+func TestWithTransactionPanicRollback(t *testing.T) {
+	db := initTxTestDB(t)
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("expected the panic to be propagated, got %v", p)
+			}
+		}()
+
+		_ = WithTransaction(context.Background(), func(tx *gorm.DB) error {
+			if err := tx.Create(&txItem{Name: "rolled back"}).Error; err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	}()
+
+	if count := countTxItems(t, db); count != 0 {
+		t.Errorf("expected 0 rows, got %d", count)
+	}
+}
+
+func TestWithTransactionNotInitialized(t *testing.T) {
+	if err := WithTransaction(context.Background(), func(*gorm.DB) error { return nil }); !errors.Is(err, ErrDBNotInitialized) {
+		t.Errorf("expected ErrDBNotInitialized, got %v", err)
+	}
+}
+
+func TestWithReadOnlyTransaction(t *testing.T) {
+	db := initTxTestDB(t)
+	if err := db.Create(&txItem{Name: "existing"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var n int64
+	err := WithReadOnlyTransaction(context.Background(), func(tx *gorm.DB) error {
+		if err := tx.Model(&txItem{}).Count(&n).Error; err != nil {
+			return err
+		}
+		return tx.Create(&txItem{Name: "write"}).Error
+	})
+	if err == nil {
+		t.Fatal("expected the write to fail")
+	}
+	if n != 1 {
+		t.Errorf("expected the read to succeed, got %d items", n)
+	}
+
+	// the connection is writable again after the transaction
+	if err := WithTransaction(context.Background(), func(tx *gorm.DB) error {
+		return tx.Create(&txItem{Name: "write"}).Error
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n := countTxItems(t, db); n != 2 {
+		t.Errorf("expected 2 items, got %d", n)
+	}
+
+	_ = CloseDB()
+	if err := WithReadOnlyTransaction(context.Background(), func(*gorm.DB) error { return nil }); !errors.Is(err, ErrDBNotInitialized) {
+		t.Errorf("expected ErrDBNotInitialized, got %v", err)
+	}
+}
+
+func TestWithRetryableTransaction(t *testing.T) {
+	db := initTxTestDB(t)
+	ctx := context.Background()
+	serializationFailure := &pgconn.PgError{Code: "40001", Message: "could not serialize access"}
+
+	calls := 0
+	err := WithRetryableTransaction(ctx, 3, func(tx *gorm.DB) error {
+		calls++
+		if err := tx.Create(&txItem{Name: "retried"}).Error; err != nil {
+			return err
+		}
+		if calls < 3 {
+			return fmt.Errorf("update: %w", serializationFailure)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+	// the rows of the failed attempts are rolled back
+	if count := countTxItems(t, db); count != 1 {
+		t.Errorf("expected 1 row, got %d", count)
+	}
+
+	// attempts exhausted
+	calls = 0
+	deadlock := &gomysql.MySQLError{Number: 1213, Message: "Deadlock found"}
+	err = WithRetryableTransaction(ctx, 2, func(*gorm.DB) error {
+		calls++
+		return deadlock
+	})
+	if !errors.Is(err, deadlock) || calls != 2 {
+		t.Errorf("expected the deadlock after 2 calls, got %v after %d", err, calls)
+	}
+
+	// other errors are not retried
+	calls = 0
+	errFailed := errors.New("failed")
+	err = WithRetryableTransaction(ctx, 3, func(*gorm.DB) error {
+		calls++
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) || calls != 1 {
+		t.Errorf("expected the error after 1 call, got %v after %d", err, calls)
+	}
+
+	// no retry once ctx is done
+	cancelled, cancel := context.WithCancel(ctx)
+	calls = 0
+	err = WithRetryableTransaction(cancelled, 3, func(*gorm.DB) error {
+		calls++
+		cancel()
+		return &pgconn.PgError{Code: "40P01"}
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("expected context.Canceled after 1 call, got %v after %d", err, calls)
+	}
+}
+
+// txRecorder - connection pool recording the options of the started
+// transactions
+type txRecorder struct {
+	*sql.DB
+	opts []*sql.TxOptions
+}
+
+// BeginTx - record opts and start the transaction
+func (r *txRecorder) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	r.opts = append(r.opts, opts)
+	return r.DB.BeginTx(ctx, opts)
+}
+
+func TestWithIsolationLevel(t *testing.T) {
+	db := initTxTestDB(t)
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := &txRecorder{DB: sqlDB}
+	pool := db.Statement.ConnPool
+	db.Statement.ConnPool = recorder
+	t.Cleanup(func() {
+		db.Statement.ConnPool = pool
+	})
+
+	ctx := context.Background()
+	noop := func(*gorm.DB) error { return nil }
+	if err := WithTransaction(ctx, noop); err != nil {
+		t.Fatal(err)
+	}
+	if err := WithTransaction(ctx, noop, WithIsolationLevel(sql.LevelSerializable)); err != nil {
+		t.Fatal(err)
+	}
+	if err := WithReadOnlyTransaction(ctx, noop, WithIsolationLevel(sql.LevelRepeatableRead)); err != nil {
+		t.Fatal(err)
+	}
+	if err := WithRetryableTransaction(ctx, 2, noop, WithIsolationLevel(sql.LevelReadCommitted)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*sql.TxOptions{
+		nil,
+		{Isolation: sql.LevelSerializable},
+		{Isolation: sql.LevelRepeatableRead, ReadOnly: true},
+		{Isolation: sql.LevelReadCommitted},
+	}
+	if len(recorder.opts) != len(want) {
+		t.Fatalf("expected %d transactions, got %d", len(want), len(recorder.opts))
+	}
+	for i, opts := range recorder.opts {
+		if (opts == nil) != (want[i] == nil) || (opts != nil && *opts != *want[i]) {
+			t.Errorf("transaction %d: expected %+v, got %+v", i, want[i], opts)
+		}
+	}
+}
+
+// testIsolationLevel - the level of the transaction is set by the
+// server, postgres only
+func testIsolationLevel(t *testing.T) {
+	var level string
+	err := WithTransaction(context.Background(), func(tx *gorm.DB) error {
+		return tx.Raw("SHOW transaction_isolation").Scan(&level).Error
+	}, WithIsolationLevel(sql.LevelSerializable))
+	if err != nil || level != "serializable" {
+		t.Errorf("expected serializable, got %q: %v", level, err)
+	}
+}
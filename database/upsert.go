@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm/clause"
+)
+
+// Upsert - insert the records of value, a model or a pointer to a
+// slice of models, and resolve the conflicts on conflictColumns
+//
+// With updateColumns, the listed columns of the existing rows are
+// overwritten with the new values (ON CONFLICT DO UPDATE). Without
+// updateColumns, the conflicting records are skipped (ON CONFLICT DO
+// NOTHING).
+//
+// postgres and sqlite3 require conflictColumns to match a primary key
+// or a unique index when updating. mysql ignores conflictColumns, all
+// primary keys and unique indexes are checked (ON DUPLICATE KEY
+// UPDATE).
+//
+//	err := database.Upsert(ctx, &products, []string{"sku"}, []string{"name", "price"})
+func Upsert(ctx context.Context, value interface{}, conflictColumns []string, updateColumns []string) error {
+	db := GetDB()
+	if db == nil {
+		return ErrDBNotInitialized
+	}
+	if len(updateColumns) > 0 && len(conflictColumns) == 0 && db.Dialector.Name() != DriverMySQL {
+		return errors.New("conflict columns are required to update the existing rows")
+	}
+
+	onConflict := clause.OnConflict{DoNothing: true}
+	if len(updateColumns) > 0 {
+		onConflict = clause.OnConflict{DoUpdates: clause.AssignmentColumns(updateColumns)}
+	}
+	for _, column := range conflictColumns {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: column})
+	}
+
+	return db.WithContext(ctx).Clauses(onConflict).Create(value).Error
+}
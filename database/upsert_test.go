@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type upsertItem struct {
+	ID    uint
+	SKU   string `gorm:"size:32;uniqueIndex"`
+	Name  string
+	Price int
+}
+
+// testUpsert - conflict resolution of Upsert on the default connection
+func testUpsert(t *testing.T, db *gorm.DB) {
+	if err := db.Migrator().DropTable(&upsertItem{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&upsertItem{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	items := []upsertItem{{SKU: "a", Name: "A", Price: 1}, {SKU: "b", Name: "B", Price: 2}}
+	if err := Upsert(ctx, &items, []string{"sku"}, []string{"name", "price"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// update mode, only the listed columns are overwritten
+	items = []upsertItem{{SKU: "a", Name: "A2", Price: 10}, {SKU: "c", Name: "C", Price: 3}}
+	if err := Upsert(ctx, &items, []string{"sku"}, []string{"price"}); err != nil {
+		t.Fatal(err)
+	}
+	var a upsertItem
+	if err := db.Where("sku = ?", "a").First(&a).Error; err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "A" || a.Price != 10 {
+		t.Errorf("expected A with price 10, got %s with price %d", a.Name, a.Price)
+	}
+
+	// do nothing mode
+	if err := Upsert(ctx, &upsertItem{SKU: "b", Name: "B2", Price: 20}, []string{"sku"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	var b upsertItem
+	if err := db.Where("sku = ?", "b").First(&b).Error; err != nil {
+		t.Fatal(err)
+	}
+	if b.Name != "B" || b.Price != 2 {
+		t.Errorf("expected the existing row to be kept, got %s with price %d", b.Name, b.Price)
+	}
+
+	var count int64
+	if err := db.Model(&upsertItem{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 rows, got %d", count)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	_ = CloseDB()
+	if err := Upsert(context.Background(), &upsertItem{}, []string{"sku"}, nil); !errors.Is(err, ErrDBNotInitialized) {
+		t.Errorf("expected ErrDBNotInitialized, got %v", err)
+	}
+
+	db, err := InitTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+
+	testUpsert(t, db)
+
+	if err := Upsert(context.Background(), &upsertItem{SKU: "a"}, nil, []string{"name"}); err == nil {
+		t.Error("expected an error without conflict columns")
+	}
+}
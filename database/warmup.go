@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// warmUp - open n connections concurrently and return them to the idle
+// pool, so that the first requests after a cold start do not pay the
+// connection-establishment cost
+//
+// n is capped by maxIdleConns, the pool closes the connections
+// exceeding it. Returns the number of connections which were opened.
+func warmUp(ctx context.Context, db *gorm.DB, n, maxIdleConns int) (int, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+
+	n = min(n, maxIdleConns)
+	if n <= 0 {
+		return 0, nil
+	}
+
+	conns := make([]*sql.Conn, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			conn, err := sqlDB.Conn(ctx)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			conns[i] = conn
+			errs[i] = conn.PingContext(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	// all connections are held until every ping returned, otherwise
+	// the same connection would be reused by the next ping
+	opened := 0
+	for i, conn := range conns {
+		if conn == nil {
+			continue
+		}
+		if errs[i] == nil {
+			opened++
+		}
+		_ = conn.Close()
+	}
+	for _, err := range errs {
+		if err != nil {
+			return opened, err
+		}
+	}
+
+	return opened, nil
+}
+
+// warmUpDB - warm up the pool of db and log the duration
+func warmUpDB(ctx context.Context, db *gorm.DB, n, maxIdleConns int) {
+	if n <= 0 {
+		return
+	}
+
+	start := time.Now()
+	opened, err := warmUp(ctx, db, n, maxIdleConns)
+	if err != nil {
+		log.WithError(err).WithField("connections", opened).Warn("database warmup failed")
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"connections": opened,
+		"elapsed":     time.Since(start),
+	}).Info("database warmup completed")
+}
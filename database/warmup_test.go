@@ -0,0 +1,39 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/pilinux/gorest/config"
+)
+
+func TestWarmUp(t *testing.T) {
+	cfg := config.RDBMS{}
+	cfg.Env.Driver = "sqlite3"
+	cfg.Access.DbName = filepath.Join(t.TempDir(), "warmup.db")
+
+	db, err := openDB(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+	sqlDB.SetMaxIdleConns(4)
+
+	opened, err := warmUp(context.Background(), db, 10, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened != 4 {
+		t.Errorf("expected 4 connections, got %d", opened)
+	}
+	if idle := sqlDB.Stats().Idle; idle != 4 {
+		t.Errorf("expected 4 idle connections, got %d", idle)
+	}
+
+	opened, err = warmUp(context.Background(), db, 0, 4)
+	if err != nil || opened != 0 {
+		t.Errorf("expected no warmup, got %d, %v", opened, err)
+	}
+}
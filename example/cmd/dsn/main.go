@@ -0,0 +1,33 @@
+// print the DSN of the configured relational database without
+// connecting to it
+//
+//	go run ./example/cmd/dsn            # password masked
+//	go run ./example/cmd/dsn -unmasked  # password in clear text
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	gconfig "github.com/pilinux/gorest/config"
+	gdatabase "github.com/pilinux/gorest/database"
+)
+
+func main() {
+	unmasked := flag.Bool("unmasked", false, "print the password in clear text")
+	flag.Parse()
+
+	// set configs
+	if err := gconfig.Config(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	dsn, err := gdatabase.PrintEffectiveDSN(!*unmasked)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(dsn)
+}
@@ -25,7 +25,7 @@ func main() {
 
 	if gconfig.IsRDBMS() {
 		// Initialize RDBMS client
-		if err := gdatabase.InitDB().Error; err != nil {
+		if _, err := gdatabase.InitDB(); err != nil {
 			fmt.Println(err)
 			return
 		}
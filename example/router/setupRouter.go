@@ -133,9 +133,17 @@ func SetupRouter(configure *gconfig.Configuration) (*gin.Engine, error) {
 		r.Use(gmiddleware.Pongo2(configure.ViewConfig.Directory))
 	}
 
+	// Bind the queries of database.FromContext(c) to the request
+	if gconfig.IsRDBMS() {
+		r.Use(gmiddleware.DBContext())
+	}
+
 	// API Status
 	r.GET("", controller.APIStatus)
 
+	// Readiness of the databases
+	r.GET("healthz", gcontroller.HealthCheck)
+
 	// API:v1.0
 	v1 := r.Group("/api/v1/")
 	{
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pilinux/gorest/database"
+	"github.com/pilinux/gorest/database/model"
+)
+
+// HealthCheck handles jobs for controller.HealthCheck
+func HealthCheck(ctx context.Context) (httpResponse model.HTTPResponse, httpStatusCode int) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := database.HealthCheck(ctx); err != nil {
+		log.WithError(err).Error("error code: 1501")
+		httpResponse.Message = "unhealthy"
+		httpStatusCode = http.StatusServiceUnavailable
+		return
+	}
+
+	httpResponse.Message = "healthy"
+	httpStatusCode = http.StatusOK
+	return
+}
@@ -6,6 +6,7 @@
 // - JWT
 // - Sentry logger
 // - Two-factor auth validator
+// - Database request context
 package middleware
 
 // github.com/pilinux/gorest
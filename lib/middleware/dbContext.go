@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RequestContextKey - key of the request context stashed by DBContext
+const RequestContextKey string = "gorest.requestContext"
+
+// DBContext - stash the context of the request, so that
+// database.FromContext(c) binds the queries of the handlers to it
+func DBContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(RequestContextKey, c.Request.Context())
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pilinux/gorest/database"
+	"github.com/pilinux/gorest/lib/middleware"
+)
+
+type ctxKey struct{}
+
+func TestDBContext(t *testing.T) {
+	if _, err := database.InitTestDB(); err != nil {
+		t.Fatal(err)
+	}
+	defer database.CloseDB()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.DBContext())
+	router.GET("/", func(c *gin.Context) {
+		db := database.FromContext(c)
+		if db.Statement.Context != c.Request.Context() {
+			t.Error("expected the connection to be bound to the request context")
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", w.Code)
+	}
+}